@@ -1,48 +1,60 @@
 package lol_prophet_gui
 
 import (
-	"fmt"
-	"github.com/avast/retry-go"
+	"context"
 	"github.com/beastars1/lol-prophet-gui/global"
-	"github.com/beastars1/lol-prophet-gui/pkg/tool"
+	"github.com/beastars1/lol-prophet-gui/services/db"
 	"github.com/beastars1/lol-prophet-gui/services/lcu"
 	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
 	"github.com/beastars1/lol-prophet-gui/services/logger"
+	"github.com/beastars1/lol-prophet-gui/services/scorer"
 	"github.com/getsentry/sentry-go"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
 const (
-	defaultScore           = 100     // 默认分数
-	minGameDurationSec     = 15 * 60 // 正常5v5时间限制 15min
-	aramMinGameDurationSec = 12 * 60 // 大乱斗时间限制 12min
+	gameHistoryPageSize = 20 // 每次查询的战绩数量
 )
 
 var (
 	SendConversationMsg   = lcu.SendConversationMsg
 	ListConversationMsg   = lcu.ListConversationMsg
 	GetCurrConversationID = lcu.GetCurrConversationID
-	QuerySummoner         = lcu.QuerySummoner
+	ListSummoner          = lcu.ListSummoner
+	QuerySummonerByPuuid  = lcu.QuerySummonerByPuuid
 	QueryGameSummary      = lcu.QueryGameSummary
-	ListGamesBySummonerID = lcu.ListGamesBySummonerID
+	ListGamesByPuuid      = lcu.ListGamesByPuuid
 )
 
-func getTeamUsers() (string, []int64, error) {
-	conversationID, err := GetCurrConversationID()
+func getTeamUsers(ctx context.Context) (string, []string, error) {
+	conversationID, err := GetCurrConversationID(ctx)
 	if err != nil {
 		return "", nil, err
 	}
-	msgList, err := ListConversationMsg(conversationID)
+	msgList, err := ListConversationMsg(ctx, conversationID)
 	if err != nil {
 		return "", nil, err
 	}
 	summonerIDList := getSummonerIDListFromConversationMsgList(msgList)
-	return conversationID, summonerIDList, nil
+	if len(summonerIDList) == 0 {
+		return conversationID, nil, nil
+	}
+	// 聊天组消息只有召唤师id,转换为puuid
+	summonerList, err := ListSummoner(ctx, summonerIDList)
+	if err != nil {
+		return "", nil, err
+	}
+	puuidList := make([]string, 0, len(summonerList))
+	for _, summoner := range summonerList {
+		puuidList = append(puuidList, summoner.Puuid)
+	}
+	return conversationID, puuidList, nil
 }
 func getSummonerIDListFromConversationMsgList(msgList []lcu.ConversationMsg) []int64 {
 	summonerIDList := make([]int64, 0, 5)
@@ -54,42 +66,55 @@ func getSummonerIDListFromConversationMsgList(msgList []lcu.ConversationMsg) []i
 	return summonerIDList
 }
 
-func GetUserScore(summonerID int64) (*lcu.UserScore, error) {
+// GetUserScore 计算玩家得分,modeName不为空时只使用该游戏模式的对局,没有该模式的对局时使用全部对局
+func GetUserScore(ctx context.Context, puuid string, modeName string) (*lcu.UserScore, error) {
 	userScoreInfo := &lcu.UserScore{
-		SummonerID: summonerID,
-		Score:      defaultScore,
+		Puuid: puuid,
+		Score: scorer.DefaultScore,
+		Mode:  modeName,
 	}
 	// 获取用户信息
-	summoner, err := QuerySummoner(summonerID)
+	summoner, err := QuerySummonerByPuuid(ctx, puuid)
 	if err != nil {
 		return nil, err
 	}
-	userScoreInfo.SummonerName = summoner.DisplayName
+	userScoreInfo.SummonerID = summoner.SummonerId
+	userScoreInfo.SummonerName = summoner.RiotID()
 	// 获取战绩列表
-	gameList, err := listGameHistory(summonerID)
+	gameList, history, fallback, err := listGameHistory(ctx, puuid, modeName)
 	if err != nil {
-		logger.Error("获取用户战绩失败", zap.Error(err), zap.Int64("id", summonerID))
+		logger.Error("获取用户战绩失败", zap.Error(err), zap.String("puuid", puuid))
 		return userScoreInfo, nil
 	}
-	// 获取每一局战绩
+	if fallback && modeName != "" {
+		userScoreInfo.Mode = ""
+		userScoreInfo.ModeFallback = true
+	}
+	userScoreInfo.Streak = scorer.CalcStreak(history, global.GetScoreConf().Streak, time.Now())
+	userScoreInfo.Account = scorer.CheckAccount(summoner.SummonerLevel, history,
+		global.GetScoreConf().Weighting.HistoryDepth, global.GetScoreConf().Smurf)
+	// 计算得分失败时也保留英雄战绩
+	userScoreInfo.Champions = scorer.ChampionStats(history, nil)
+	// 获取每一局战绩,行为标记需要包括时长不足等不参与评分的对局
 	g := errgroup.Group{}
 	gameSummaryList := make([]lcu.GameSummary, 0, len(gameList))
+	historySummaryList := make([]lcu.GameSummary, 0, len(history))
 	mu := sync.Mutex{}
 	currKDAList := make([][3]int, len(gameList))
+	scoredGameIDs := make(map[int64]struct{}, len(gameList))
 	for i, info := range gameList {
-		info := info
 		currKDAList[len(gameList)-i-1] = [3]int{
 			info.Participants[0].Stats.Kills,
 			info.Participants[0].Stats.Deaths,
 			info.Participants[0].Stats.Assists,
 		}
+		scoredGameIDs[info.GameId] = struct{}{}
+	}
+	for _, info := range history {
+		info := info
 		g.Go(func() error {
-			var gameSummary *lcu.GameSummary
-			err = retry.Do(func() error {
-				var tmpErr error
-				gameSummary, tmpErr = QueryGameSummary(info.GameId)
-				return tmpErr
-			}, retry.Delay(time.Millisecond*10), retry.Attempts(5))
+			// 客户端请求已按 lcu.DefaultRetryPolicy 重试临时性错误
+			gameSummary, err := getGameSummary(ctx, info.GameId)
 			if err != nil {
 				sentry.WithScope(func(scope *sentry.Scope) {
 					scope.SetLevel(sentry.LevelError)
@@ -105,7 +130,10 @@ func GetUserScore(summonerID int64) (*lcu.UserScore, error) {
 				return nil
 			}
 			mu.Lock()
-			gameSummaryList = append(gameSummaryList, *gameSummary)
+			historySummaryList = append(historySummaryList, *gameSummary)
+			if _, ok := scoredGameIDs[info.GameId]; ok {
+				gameSummaryList = append(gameSummaryList, *gameSummary)
+			}
 			mu.Unlock()
 			return nil
 		})
@@ -113,425 +141,179 @@ func GetUserScore(summonerID int64) (*lcu.UserScore, error) {
 	userScoreInfo.CurrKDA = currKDAList
 	err = g.Wait()
 	if err != nil {
-		logger.Error("获取用户详细战绩失败", zap.Error(err), zap.Int64("id", summonerID))
+		logger.Error("获取用户详细战绩失败", zap.Error(err), zap.String("puuid", puuid))
 		return userScoreInfo, nil
 	}
+	scoreConf := global.GetScoreConf()
+	userScoreInfo.Badges = scorer.Badges(historySummaryList, puuid, scoreConf.Behavior)
 	// 分析每一局战绩计算得分
-	var totalScore float64 = 0
-	totalGameCount := 0
-	type gameScoreWithWeight struct {
-		score       float64
-		isCurrTimes bool
-	}
-	// gameWeightScoreList := make([]gameScoreWithWeight, 0, len(gameSummaryList))
-	nowTime := time.Now()
-	currTimeScoreList := make([]float64, 0, 10)
-	otherGameScoreList := make([]float64, 0, 10)
-	for _, gameSummary := range gameSummaryList {
-		gameScore, err := calcUserGameScore(summonerID, gameSummary)
-		if err != nil {
-			logger.Debug("游戏战绩计算用户得分失败", zap.Error(err), zap.Int64("summonerID", summonerID),
-				zap.Int64("gameID", gameSummary.GameId))
-			return userScoreInfo, nil
-		}
-		weightScoreItem := gameScoreWithWeight{
-			score:       gameScore.Value(),
-			isCurrTimes: nowTime.Before(gameSummary.GameCreationDate.Add(time.Hour * 5)),
-		}
-		if weightScoreItem.isCurrTimes {
-			currTimeScoreList = append(currTimeScoreList, gameScore.Value())
-		} else {
-			otherGameScoreList = append(otherGameScoreList, gameScore.Value())
-		}
-		totalGameCount++
-		totalScore += gameScore.Value()
-		// log.Printf("game: %d,得分: %.2f\n", gameSummary.GameId, gameScore)
-	}
-	totalGameScore := 0.0
-	totalTimeScore := 0.0
-	avgTimeScore := 0.0
-	totalOtherGameScore := 0.0
-	avgOtherGameScore := 0.0
-	for _, score := range currTimeScoreList {
-		totalTimeScore += score
-		totalGameScore += score
-	}
-	for _, score := range otherGameScoreList {
-		totalOtherGameScore += score
-		totalGameScore += score
-	}
-	if totalTimeScore > 0 {
-		avgTimeScore = totalTimeScore / float64(len(currTimeScoreList))
-	}
-	if totalOtherGameScore > 0 {
-		avgOtherGameScore = totalOtherGameScore / float64(len(otherGameScoreList))
-	}
-	totalGameAvgScore := 0.0
-	if totalGameCount > 0 {
-		totalGameAvgScore = totalGameScore / float64(totalGameCount)
-	}
-	weightTotalScore := 0.0
-	// curr time
-	{
-		if len(currTimeScoreList) == 0 {
-			weightTotalScore += .8 * totalGameAvgScore
-		} else {
-			weightTotalScore += .8 * avgTimeScore
-		}
+	gameScoreList, err := scoreGames(puuid, gameSummaryList, scorer.New(scoreConf))
+	if err != nil {
+		logger.Debug("游戏战绩计算用户得分失败", zap.Error(err), zap.String("puuid", puuid))
+		return userScoreInfo, nil
 	}
-	// other games
-	{
-		if len(otherGameScoreList) == 0 {
-			weightTotalScore += .2 * totalGameAvgScore
-		} else {
-			weightTotalScore += .2 * avgOtherGameScore
+	weighted := scorer.Weigh(gameScoreList, scoreConf.Weighting, time.Now())
+	userScoreInfo.Score = weighted.Score
+	userScoreInfo.EffectiveGames = weighted.EffectiveGames
+	userScoreInfo.Confidence = weighted.Confidence
+	userScoreInfo.Games = gameScoreList
+	if laning, ok := scorer.AverageLaning(gameScoreList); ok {
+		userScoreInfo.Laning = &laning
+	}
+	userScoreInfo.Champions = scorer.ChampionStats(history, gameScoreList)
+	// 配置了自定义模型时,同时给出各模型的得分用于对比
+	if len(scoreConf.ScoreModels) > 0 {
+		userScoreInfo.ModelScores = make(map[string]float64, len(scoreConf.ScoreModels)+1)
+		for _, s := range scorer.All(scoreConf) {
+			if modelGameScoreList, err := scoreGames(puuid, gameSummaryList, s); err == nil {
+				userScoreInfo.ModelScores[s.Name()] = scorer.Weigh(modelGameScoreList, scoreConf.Weighting, time.Now()).Score
+			}
 		}
 	}
-	// 计算平均值返回
-	// userScoreInfo.Score = totalScore / float64(totalGameCount)
-	if len(gameSummaryList) == 0 {
-		weightTotalScore = defaultScore
-	}
-	userScoreInfo.Score = weightTotalScore
 	return userScoreInfo, nil
 }
 
-func listGameHistory(summonerID int64) ([]lcu.GameInfo, error) {
-	fmtList := make([]lcu.GameInfo, 0, 20)
-	resp, err := ListGamesBySummonerID(summonerID, 0, 20)
-	if err != nil {
-		logger.Error("查询用户战绩失败", zap.Error(err), zap.Int64("summonerID", summonerID))
-		return nil, err
-	}
-	for _, gameItem := range resp.Games.Games {
-		if gameItem.QueueId != models.NormalQueueID &&
-			gameItem.QueueId != models.RankSoleQueueID &&
-			gameItem.QueueId != models.ARAMQueueID &&
-			gameItem.QueueId != models.RankFlexQueueID {
-			continue
-		}
-		// 过滤掉游戏时长不足的大乱斗对局
-		if gameItem.QueueId == models.ARAMQueueID && gameItem.GameDuration < aramMinGameDurationSec {
-			continue
-		}
-		// 过滤掉游戏时长不足的峡谷对局
-		if gameItem.QueueId != models.ARAMQueueID && gameItem.GameDuration < minGameDurationSec {
-			continue
-		}
-		fmtList = append(fmtList, gameItem)
+// scoreGames 使用指定的评分算法计算每一局得分,按时间倒序返回
+func scoreGames(puuid string, gameSummaryList []lcu.GameSummary, s scorer.Scorer) ([]lcu.GameScore, error) {
+	gameScoreList := make([]lcu.GameScore, 0, len(gameSummaryList))
+	for i := range gameSummaryList {
+		gameSummary := &gameSummaryList[i]
+		gameScore, err := s.Score(puuid, gameSummary)
+		if err != nil {
+			return nil, errors.Wrapf(err, "对局%d", gameSummary.GameId)
+		}
+		if global.IsDevMode() {
+			log.Printf("[%s]对局%d得分:%.2f,原因:%s", s.Name(), gameSummary.GameId, gameScore.Value(),
+				gameScore.Reasons2String())
+		}
+		participant := gameSummary.FindParticipant(puuid)
+		var laningScore *float64
+		if laning, ok := scorer.Laning(gameSummary, puuid, global.GetScoreConf().Laning); ok {
+			laningScore = &laning
+		}
+		gameScoreList = append(gameScoreList, lcu.GameScore{
+			GameID:       gameSummary.GameId,
+			ChampionID:   participant.ChampionId,
+			QueueID:      models.GameQueueID(gameSummary.QueueId),
+			Position:     scorer.DetectPosition(gameSummary, puuid),
+			GameCreation: gameSummary.GameCreationDate,
+			KDA:          [3]int{participant.Stats.Kills, participant.Stats.Deaths, participant.Stats.Assists},
+			Win:          participant.Stats.Win,
+			Score:        gameScore.Value(),
+			Reasons:      gameScore.Reasons(),
+			Laning:       laningScore,
+			Behaviors:    scorer.GameBehaviors(gameSummary, puuid, global.GetScoreConf().Behavior),
+		})
 	}
-	return fmtList, nil
+	sort.Slice(gameScoreList, func(i, j int) bool {
+		return gameScoreList[i].GameCreation.After(gameScoreList[j].GameCreation)
+	})
+	return gameScoreList, nil
 }
 
-func calcUserGameScore(summonerID int64, gameSummary lcu.GameSummary) (*lcu.ScoreWithReason, error) {
-	calcScoreConf := global.GetScoreConf()
-	gameScore := lcu.NewScoreWithReason(defaultScore)
-	var userParticipantId int
-	for _, identity := range gameSummary.ParticipantIdentities {
-		if identity.Player.SummonerId == summonerID {
-			userParticipantId = identity.ParticipantId
-		}
+// getGameSummary 优先读取本地对局记录,本地没有时再从客户端查询并保存
+func getGameSummary(ctx context.Context, gameID int64) (*lcu.GameSummary, error) {
+	if global.SqliteDB == nil {
+		return QueryGameSummary(ctx, gameID)
 	}
-	if userParticipantId == 0 {
-		return nil, errors.New("获取用户位置失败")
+	repo := db.NewGameRepo(global.SqliteDB)
+	gameSummary, err := repo.GetGameSummary(ctx, gameID)
+	if err == nil {
+		return gameSummary, nil
 	}
-	var userTeamID *models.TeamID
-	memberParticipantIDList := make([]int, 0, 4)
-	idMapParticipant := make(map[int]lcu.Participant, len(gameSummary.Participants))
-	for _, item := range gameSummary.Participants {
-		if item.ParticipantId == userParticipantId {
-			userTeamID = &item.TeamId
-		}
-		idMapParticipant[item.ParticipantId] = item
-	}
-	if userTeamID == nil {
-		return nil, errors.New("获取用户队伍id失败")
-	}
-	for _, item := range gameSummary.Participants {
-		if item.TeamId == *userTeamID {
-			memberParticipantIDList = append(memberParticipantIDList, item.ParticipantId)
-		}
+	if !errors.Is(err, db.ErrGameNotFound) {
+		logger.Info("读取本地对局记录失败", zap.Error(err), zap.Int64("gameID", gameID))
 	}
-	totalKill := 0   // 总人头
-	totalDeath := 0  // 总死亡
-	totalAssist := 0 // 总助攻
-	totalHurt := 0   // 总伤害
-	totalMoney := 0  // 总金钱
-	for _, participant := range gameSummary.Participants {
-		if participant.TeamId != *userTeamID {
-			continue
-		}
-		totalKill += participant.Stats.Kills
-		totalDeath += participant.Stats.Deaths
-		totalAssist += participant.Stats.Assists
-		totalHurt += participant.Stats.TotalDamageDealtToChampions
-		totalMoney += participant.Stats.GoldEarned
-	}
-	userParticipant := idMapParticipant[userParticipantId]
-	isSupportRole := userParticipant.Timeline.Lane == models.LaneBottom &&
-		userParticipant.Timeline.Role == models.ChampionRoleSupport
-	// 一血击杀
-	if userParticipant.Stats.FirstBloodKill {
-		gameScore.Add(calcScoreConf.FirstBlood[0], lcu.ScoreOptionFirstBloodKill)
-		// 一血助攻
-	} else if userParticipant.Stats.FirstBloodAssist {
-		gameScore.Add(calcScoreConf.FirstBlood[1], lcu.ScoreOptionFirstBloodAssist)
-	}
-	// 五杀
-	if userParticipant.Stats.PentaKills > 0 {
-		gameScore.Add(calcScoreConf.PentaKills[0], lcu.ScoreOptionPentaKills)
-		// 四杀
-	} else if userParticipant.Stats.QuadraKills > 0 {
-		gameScore.Add(calcScoreConf.QuadraKills[0], lcu.ScoreOptionQuadraKills)
-		// 三杀
-	} else if userParticipant.Stats.TripleKills > 0 {
-		gameScore.Add(calcScoreConf.TripleKills[0], lcu.ScoreOptionTripleKills)
-	}
-	// 参团率
-	if totalKill > 0 {
-		joinTeamRateRank := 1
-		userJoinTeamKillRate := float64(userParticipant.Stats.Assists+userParticipant.Stats.Kills) / float64(
-			totalKill)
-		memberJoinTeamKillRates := listMemberJoinTeamKillRates(&gameSummary, totalKill, memberParticipantIDList)
-		for _, rate := range memberJoinTeamKillRates {
-			if rate > userJoinTeamKillRate {
-				joinTeamRateRank++
-			}
-		}
-		if joinTeamRateRank == 1 {
-			gameScore.Add(calcScoreConf.JoinTeamRateRank[0], lcu.ScoreOptionJoinTeamRateRank)
-		} else if joinTeamRateRank == 2 {
-			gameScore.Add(calcScoreConf.JoinTeamRateRank[1], lcu.ScoreOptionJoinTeamRateRank)
-		} else if joinTeamRateRank == 4 {
-			gameScore.Add(-calcScoreConf.JoinTeamRateRank[2], lcu.ScoreOptionJoinTeamRateRank)
-		} else if joinTeamRateRank == 5 {
-			gameScore.Add(-calcScoreConf.JoinTeamRateRank[3], lcu.ScoreOptionJoinTeamRateRank)
-		}
-	}
-	// 获取金钱
-	if totalMoney > 0 {
-		moneyRank := 1
-		userMoney := userParticipant.Stats.GoldEarned
-		memberMoneyList := listMemberMoney(&gameSummary, memberParticipantIDList)
-		for _, v := range memberMoneyList {
-			if v > userMoney {
-				moneyRank++
-			}
-		}
-		if moneyRank == 1 {
-			gameScore.Add(calcScoreConf.GoldEarnedRank[0], lcu.ScoreOptionGoldEarnedRank)
-		} else if moneyRank == 2 {
-			gameScore.Add(calcScoreConf.GoldEarnedRank[1], lcu.ScoreOptionGoldEarnedRank)
-		} else if moneyRank == 4 && !isSupportRole {
-			gameScore.Add(-calcScoreConf.GoldEarnedRank[2], lcu.ScoreOptionGoldEarnedRank)
-		} else if moneyRank == 5 && !isSupportRole {
-			gameScore.Add(-calcScoreConf.GoldEarnedRank[3], lcu.ScoreOptionGoldEarnedRank)
-		}
-	}
-	// 伤害占比
-	if totalHurt > 0 {
-		hurtRank := 1
-		userHurt := userParticipant.Stats.TotalDamageDealtToChampions
-		memberHurtList := listMemberHurt(&gameSummary, memberParticipantIDList)
-		for _, v := range memberHurtList {
-			if v > userHurt {
-				hurtRank++
-			}
-		}
-		if hurtRank == 1 {
-			gameScore.Add(calcScoreConf.HurtRank[0], lcu.ScoreOptionHurtRank)
-		} else if hurtRank == 2 {
-			gameScore.Add(calcScoreConf.HurtRank[1], lcu.ScoreOptionHurtRank)
-		}
-	}
-	// 金钱转换伤害比
-	if totalMoney > 0 && totalHurt > 0 {
-		money2hurtRateRank := 1
-		userMoney2hurtRate := float64(userParticipant.Stats.TotalDamageDealtToChampions) / float64(userParticipant.Stats.
-			GoldEarned)
-		memberMoney2hurtRateList := listMemberMoney2hurtRate(&gameSummary, memberParticipantIDList)
-		for _, v := range memberMoney2hurtRateList {
-			if v > userMoney2hurtRate {
-				money2hurtRateRank++
-			}
-		}
-		if money2hurtRateRank == 1 {
-			gameScore.Add(calcScoreConf.Money2hurtRateRank[0], lcu.ScoreOptionMoney2hurtRateRank)
-		} else if money2hurtRateRank == 2 {
-			gameScore.Add(calcScoreConf.Money2hurtRateRank[1], lcu.ScoreOptionMoney2hurtRateRank)
-		}
-	}
-	// 视野得分
-	{
-		visionScoreRank := 1
-		userVisionScore := userParticipant.Stats.VisionScore
-		memberVisionScoreList := listMemberVisionScore(&gameSummary, memberParticipantIDList)
-		for _, v := range memberVisionScoreList {
-			if v > userVisionScore {
-				visionScoreRank++
-			}
-		}
-		if visionScoreRank == 1 {
-			gameScore.Add(calcScoreConf.VisionScoreRank[0], lcu.ScoreOptionVisionScoreRank)
-		} else if visionScoreRank == 2 {
-			gameScore.Add(calcScoreConf.VisionScoreRank[1], lcu.ScoreOptionVisionScoreRank)
-		}
-	}
-	// 补兵 每分钟8个刀以上加5分 ,9+10, 10+20
-	{
-		totalMinionsKilled := userParticipant.Stats.TotalMinionsKilled
-		gameDurationMinute := gameSummary.GameDuration / 60
-		minuteMinionsKilled := totalMinionsKilled / gameDurationMinute
-		for _, minionsKilledLimit := range calcScoreConf.MinionsKilled {
-			if minuteMinionsKilled >= int(minionsKilledLimit[0]) {
-				gameScore.Add(minionsKilledLimit[1], lcu.ScoreOptionMinionsKilled)
-				break
-			}
-		}
-	}
-	// 人头占比
-	if totalKill > 0 {
-		// 人头占比>50%
-		userKillRate := float64(userParticipant.Stats.Kills) / float64(totalKill)
-	userKillRateLoop:
-		for _, killRateConfItem := range calcScoreConf.KillRate {
-			if userKillRate > killRateConfItem.Limit {
-				for _, limitConf := range killRateConfItem.ScoreConf {
-					if userParticipant.Stats.Kills > int(limitConf[0]) {
-						gameScore.Add(limitConf[1], lcu.ScoreOptionKillRate)
-						break userKillRateLoop
-					}
-				}
-			}
-		}
-	}
-	// 伤害占比
-	if totalHurt > 0 {
-		// 伤害占比>50%
-		userHurtRate := float64(userParticipant.Stats.TotalDamageDealtToChampions) / float64(totalHurt)
-	userHurtRateLoop:
-		for _, killRateConfItem := range calcScoreConf.HurtRate {
-			if userHurtRate > killRateConfItem.Limit {
-				for _, limitConf := range killRateConfItem.ScoreConf {
-					if userParticipant.Stats.Kills > int(limitConf[0]) {
-						gameScore.Add(limitConf[1], lcu.ScoreOptionHurtRate)
-						break userHurtRateLoop
-					}
-				}
-			}
-		}
+	gameSummary, err = QueryGameSummary(ctx, gameID)
+	if err != nil {
+		return nil, err
 	}
-	// 助攻占比
-	if totalAssist > 0 {
-		// 助攻占比>50%
-		userAssistRate := float64(userParticipant.Stats.Assists) / float64(totalAssist)
-	userAssistRateLoop:
-		for _, killRateConfItem := range calcScoreConf.AssistRate {
-			if userAssistRate > killRateConfItem.Limit {
-				for _, limitConf := range killRateConfItem.ScoreConf {
-					if userParticipant.Stats.Kills > int(limitConf[0]) {
-						gameScore.Add(limitConf[1], lcu.ScoreOptionAssistRate)
-						break userAssistRateLoop
-					}
-				}
-			}
-		}
+	if err = repo.SaveGameSummary(ctx, gameSummary); err != nil {
+		logger.Info("保存对局记录失败", zap.Error(err), zap.Int64("gameID", gameID))
 	}
-	userJoinTeamKillRate := 1.0
-	if totalKill > 0 {
-		userJoinTeamKillRate = float64(userParticipant.Stats.Assists+userParticipant.Stats.Kills) / float64(
-			totalKill)
-	}
-	userDeathTimes := userParticipant.Stats.Deaths
-	if userParticipant.Stats.Deaths == 0 {
-		userDeathTimes = 1
-	}
-	adjustVal := (float64(userParticipant.Stats.Kills+userParticipant.Stats.Assists)/float64(userDeathTimes) -
-		calcScoreConf.AdjustKDA[0] +
-		float64(userParticipant.Stats.Kills-userParticipant.Stats.Deaths)/calcScoreConf.AdjustKDA[1]) * userJoinTeamKillRate
-	// log.Printf("game: %d,kda: %d/%d/%d\n", gameSummary.GameId, userParticipant.Stats.Kills,
-	// 	userParticipant.Stats.Deaths, userParticipant.Stats.Assists)
-	gameScore.Add(adjustVal, lcu.ScoreOptionKDAAdjust)
-	kdaInfoStr := fmt.Sprintf("%d/%d/%d", userParticipant.Stats.Kills, userParticipant.Stats.Deaths,
-		userParticipant.Stats.Assists)
-	if global.IsDevMode() {
-		log.Printf("对局%d得分:%.2f, kda:%s,原因:%s", gameSummary.GameId, gameScore.Value(), kdaInfoStr, gameScore.Reasons2String())
-	}
-	return gameScore, nil
+	return gameSummary, nil
 }
 
-func listMemberVisionScore(gameSummary *lcu.GameSummary, memberParticipantIDList []int) []int {
-	res := make([]int, 0, 4)
-	for _, participant := range gameSummary.Participants {
-		if !tool.InArrayInt(participant.ParticipantId, memberParticipantIDList) {
-			continue
+// listGamesByPage 分页获取最近depth局战绩,客户端返回不足一页时结束
+func listGamesByPage(ctx context.Context, puuid string, depth int) ([]lcu.GameInfo, error) {
+	gameList := make([]lcu.GameInfo, 0, depth)
+	for begin := 0; begin < depth; begin += gameHistoryPageSize {
+		limit := gameHistoryPageSize
+		if depth-begin < limit {
+			limit = depth - begin
+		}
+		resp, err := ListGamesByPuuid(ctx, puuid, begin, limit)
+		if err != nil {
+			return nil, err
 		}
-		res = append(res, participant.Stats.VisionScore)
-	}
-	return res
-}
-
-func listMemberMoney2hurtRate(gameSummary *lcu.GameSummary, memberParticipantIDList []int) []float64 {
-	res := make([]float64, 0, 4)
-	for _, participant := range gameSummary.Participants {
-		if !tool.InArrayInt(participant.ParticipantId, memberParticipantIDList) {
-			continue
+		gameList = append(gameList, resp.Games.Games...)
+		if len(resp.Games.Games) < limit {
+			break
 		}
-		res = append(res, float64(participant.Stats.TotalDamageDealtToChampions)/float64(participant.Stats.
-			GoldEarned))
 	}
-	return res
+	return gameList, nil
 }
 
-func listMemberMoney(gameSummary *lcu.GameSummary, memberParticipantIDList []int) []int {
-	res := make([]int, 0, 4)
-	for _, participant := range gameSummary.Participants {
-		if !tool.InArrayInt(participant.ParticipantId, memberParticipantIDList) {
+// listGameHistory 返回参与评分的对局以及未过滤的全部战绩
+// 当前模式没有对局时使用全部模式的对局,fallback 为 true
+func listGameHistory(ctx context.Context, puuid string, modeName string) (modeGames []lcu.GameInfo,
+	history []lcu.GameInfo, fallback bool, err error) {
+	scoreConf := global.GetScoreConf()
+	gameList, err := listGamesByPage(ctx, puuid, scoreConf.Weighting.HistoryDepth)
+	if err != nil {
+		logger.Error("查询用户战绩失败", zap.Error(err), zap.String("puuid", puuid))
+		return nil, nil, false, err
+	}
+	fmtList := make([]lcu.GameInfo, 0, len(gameList))
+	modeList := make([]lcu.GameInfo, 0, len(gameList))
+	for _, gameItem := range gameList {
+		// 不属于任何评分模式的对局不参与计算
+		mode := scoreConf.ModeOf(int(gameItem.QueueId), string(gameItem.GameMode))
+		if mode == nil {
 			continue
 		}
-		res = append(res, participant.Stats.GoldEarned)
-	}
-	return res
-}
-
-func listMemberJoinTeamKillRates(gameSummary *lcu.GameSummary, totalKill int, memberParticipantIDList []int) []float64 {
-	res := make([]float64, 0, 4)
-	for _, participant := range gameSummary.Participants {
-		if !tool.InArrayInt(participant.ParticipantId, memberParticipantIDList) {
+		// 过滤掉游戏时长不足的对局
+		if gameItem.GameDuration < mode.MinDurationSec {
 			continue
 		}
-		res = append(res, float64(participant.Stats.Assists+participant.Stats.Kills)/float64(
-			totalKill))
+		fmtList = append(fmtList, gameItem)
+		if mode.Name == modeName {
+			modeList = append(modeList, gameItem)
+		}
+	}
+	if len(modeList) > 0 {
+		return modeList, gameList, false, nil
 	}
-	return res
+	return fmtList, gameList, true, nil
 }
 
-func listMemberHurt(gameSummary *lcu.GameSummary, memberParticipantIDList []int) []int {
-	res := make([]int, 0, 4)
-	for _, participant := range gameSummary.Participants {
-		if !tool.InArrayInt(participant.ParticipantId, memberParticipantIDList) {
-			continue
+// championsFromSession 游戏中每个玩家使用的英雄
+func championsFromSession(session *lcu.GameFlowSession) map[string]int {
+	champions := make(map[string]int, len(session.GameData.TeamOne)+len(session.GameData.TeamTwo))
+	for _, team := range [][]lcu.GameFolwSessionTeamUser{session.GameData.TeamOne, session.GameData.TeamTwo} {
+		for _, user := range team {
+			if user.Puuid != "" && user.ChampionId > 0 {
+				champions[user.Puuid] = user.ChampionId
+			}
 		}
-		res = append(res, participant.Stats.TotalDamageDealtToChampions)
 	}
-	return res
+	return champions
 }
-func getAllUsersFromSession(selfID int64, session *lcu.GameFlowSession) (selfTeamUsers []int64,
-	enemyTeamUsers []int64) {
-	selfTeamUsers = make([]int64, 0, 5)
-	enemyTeamUsers = make([]int64, 0, 5)
+
+func getAllUsersFromSession(selfPuuid string, session *lcu.GameFlowSession) (selfTeamUsers []string,
+	enemyTeamUsers []string) {
+	selfTeamUsers = make([]string, 0, 5)
+	enemyTeamUsers = make([]string, 0, 5)
 	selfTeamID := models.TeamIDNone
 	for _, teamUser := range session.GameData.TeamOne {
-		summonerID := int64(teamUser.SummonerId)
-		if selfID == summonerID {
+		if selfPuuid == teamUser.Puuid {
 			selfTeamID = models.TeamIDBlue
 			break
 		}
 	}
 	if selfTeamID == models.TeamIDNone {
 		for _, teamUser := range session.GameData.TeamTwo {
-			summonerID := int64(teamUser.SummonerId)
-			if selfID == summonerID {
+			if selfPuuid == teamUser.Puuid {
 				selfTeamID = models.TeamIDRed
 				break
 			}
@@ -541,25 +323,23 @@ func getAllUsersFromSession(selfID int64, session *lcu.GameFlowSession) (selfTea
 		return
 	}
 	for _, user := range session.GameData.TeamOne {
-		userID := int64(user.SummonerId)
-		if userID <= 0 {
+		if user.Puuid == "" {
 			return
 		}
 		if models.TeamIDBlue == selfTeamID {
-			selfTeamUsers = append(selfTeamUsers, userID)
+			selfTeamUsers = append(selfTeamUsers, user.Puuid)
 		} else {
-			enemyTeamUsers = append(enemyTeamUsers, userID)
+			enemyTeamUsers = append(enemyTeamUsers, user.Puuid)
 		}
 	}
 	for _, user := range session.GameData.TeamTwo {
-		userID := int64(user.SummonerId)
-		if userID <= 0 {
+		if user.Puuid == "" {
 			return
 		}
 		if models.TeamIDRed == selfTeamID {
-			selfTeamUsers = append(selfTeamUsers, userID)
+			selfTeamUsers = append(selfTeamUsers, user.Puuid)
 		} else {
-			enemyTeamUsers = append(enemyTeamUsers, userID)
+			enemyTeamUsers = append(enemyTeamUsers, user.Puuid)
 		}
 	}
 	return
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package bootstrap
+
+// 非windows系统(wine/proton)下读取 /proc 与 lockfile 不需要管理员权限
+func mustRunWithAdmin() {}
+
+func initConsole() {}
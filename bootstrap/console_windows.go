@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package bootstrap
+
+import (
+	"os"
+
+	"github.com/beastars1/lol-prophet-gui/pkg/windows/admin"
+	"golang.org/x/sys/windows"
+)
+
+func mustRunWithAdmin() {
+	admin.MustRunWithAdmin()
+}
+
+func initConsole() {
+	stdIn := windows.Handle(os.Stdin.Fd())
+	var consoleMode uint32
+	_ = windows.GetConsoleMode(stdIn, &consoleMode)
+	consoleMode = consoleMode&^windows.ENABLE_QUICK_EDIT_MODE | windows.ENABLE_EXTENDED_FLAGS
+	_ = windows.SetConsoleMode(stdIn, consoleMode)
+}
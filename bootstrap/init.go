@@ -7,10 +7,11 @@ import (
 	"github.com/beastars1/lol-prophet-gui/global"
 	"github.com/beastars1/lol-prophet-gui/pkg/logger"
 	"github.com/beastars1/lol-prophet-gui/pkg/tool"
-	"github.com/beastars1/lol-prophet-gui/pkg/windows/admin"
+	"github.com/beastars1/lol-prophet-gui/services/db"
 	"github.com/beastars1/lol-prophet-gui/services/db/enity"
 	"github.com/beastars1/lol-prophet-gui/services/ws"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -23,7 +24,6 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"golang.org/x/sys/windows"
 	"gopkg.in/natefinch/lumberjack.v2"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -35,13 +35,7 @@ const (
 )
 
 func initConf() {
-	_ = godotenv.Load(".env")
-	if tool.IsFile(".env.local") {
-		_ = godotenv.Overload(".env.local")
-	}
-
-	*global.Conf = global.DefaultAppConf
-	err := configor.Load(global.Conf)
+	err := loadAppConf()
 	if err != nil {
 		panic(err)
 	}
@@ -51,42 +45,115 @@ func initConf() {
 	}
 }
 
+// loadAppConf 读取默认配置并应用 .env 及环境变量的覆盖
+func loadAppConf() error {
+	_ = godotenv.Load(".env")
+	if tool.IsFile(".env.local") {
+		_ = godotenv.Overload(".env.local")
+	}
+
+	*global.Conf = global.DefaultAppConf
+	return configor.Load(global.Conf)
+}
+
 func initClientConf() (err error) {
-	dbPath := conf.SqliteDBPath
-	var db *gorm.DB
-	var dbLogger = gormLogger.Discard
-	if global.IsDevMode() {
-		dbLogger = gormLogger.Default
+	sqliteDB, err := openSqliteDB(conf.SqliteDBPath)
+	if err != nil {
+		return
 	}
-	gormCfg := &gorm.Config{
-		Logger: dbLogger,
+	err = db.Migrate(sqliteDB, conf.SqliteDBPath, db.Migrations)
+	if err != nil {
+		return
 	}
-	if !tool.IsFile(dbPath) {
-		db, err = gorm.Open(sqlite.Open(dbPath), gormCfg)
+	confItem := &enity.Config{}
+	err = sqliteDB.Table("config").Where("k = ?", enity.LocalClientConfKey).First(confItem).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		bts, _ := json.Marshal(global.DefaultClientConf)
-		err = db.Exec(enity.InitLocalClientSql, enity.LocalClientConfKey, string(bts)).Error
+		err = sqliteDB.Create(&enity.Config{Key: enity.LocalClientConfKey, Val: string(bts)}).Error
 		if err != nil {
 			return
 		}
 		*global.ClientConf = global.DefaultClientConf
+	} else if err != nil {
+		return
 	} else {
-		db, err = gorm.Open(sqlite.Open(dbPath), gormCfg)
-		confItem := &enity.Config{}
-		err = db.Table("config").Where("k = ?", enity.LocalClientConfKey).First(confItem).Error
+		res := conf.LoadClientConf([]byte(confItem.Val), global.DefaultClientConf)
+		err = saveLoadedClientConf(sqliteDB, confItem, res)
 		if err != nil {
 			return
 		}
-		localClientConf := &conf.Client{}
-		err = json.Unmarshal([]byte(confItem.Val), localClientConf)
-		if err != nil || conf.ValidClientConf(localClientConf) != nil {
-			return errors.New("本地配置错误")
-		}
-		global.ClientConf = localClientConf
+		global.ClientConf = res.Conf
+	}
+	global.SqliteDB = sqliteDB
+	return initScoreConf(sqliteDB)
+}
+
+// initScoreConf 读取本地保存的评分配置,没有时保存当前配置
+func initScoreConf(sqliteDB *gorm.DB) error {
+	confItem := &enity.Config{}
+	err := sqliteDB.Table("config").Where("k = ?", enity.LocalScoreConfKey).First(confItem).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		bts, _ := json.Marshal(global.GetScoreConf())
+		return sqliteDB.Create(&enity.Config{Key: enity.LocalScoreConfKey, Val: string(bts)}).Error
 	}
-	global.SqliteDB = db
+	if err != nil {
+		return err
+	}
+	scoreConf, err := conf.ParseScoreConf([]byte(confItem.Val), *global.GetScoreConf())
+	if err != nil {
+		log.Printf("本地评分配置错误,使用默认评分配置: %v\n", err)
+		return nil
+	}
+	global.SetScoreConf(*scoreConf)
 	return nil
 }
 
+// saveLoadedClientConf 本地配置迁移或修复后写回,有错误时先备份原始配置
+func saveLoadedClientConf(sqliteDB *gorm.DB, confItem *enity.Config, res conf.ClientConfLoadResult) error {
+	if !res.NeedSave() {
+		return nil
+	}
+	return sqliteDB.Transaction(func(tx *gorm.DB) error {
+		if res.NeedBackup() {
+			backupKey := enity.LocalClientConfKey + ".bak." + time.Now().Format("20060102150405")
+			log.Printf("本地配置错误,已备份为 %s, 重置字段: %v\n", backupKey, res.InvalidFields)
+			err := tx.Create(&enity.Config{Key: backupKey, Val: confItem.Val}).Error
+			if err != nil {
+				return err
+			}
+		}
+		bts, _ := json.Marshal(res.Conf)
+		return tx.Model(&enity.Config{}).Where("k = ?", enity.LocalClientConfKey).Update("v", string(bts)).Error
+	})
+}
+
+func openSqliteDB(dbPath string) (*gorm.DB, error) {
+	var dbLogger = gormLogger.Discard
+	if global.IsDevMode() {
+		dbLogger = gormLogger.Default
+	}
+	return gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: dbLogger,
+	})
+}
+
+// SchemaVersion 返回本地数据库当前结构版本以及程序支持的最新版本,不执行迁移
+func SchemaVersion() (current int, latest int, err error) {
+	latest = db.LatestVersion(db.Migrations)
+	if !tool.IsFile(conf.SqliteDBPath) {
+		return 0, latest, nil
+	}
+	sqliteDB, err := openSqliteDB(conf.SqliteDBPath)
+	if err != nil {
+		return 0, latest, err
+	}
+	if sqlDB, err := sqliteDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	current, err = db.SchemaVersion(sqliteDB)
+	return current, latest, err
+}
+
 func initLog(cfg *conf.LogConf) {
 	writeSyncer := zapcore.AddSync(&lumberjack.Logger{
 		Filename:   cfg.Filepath,
@@ -115,7 +182,7 @@ func initLog(cfg *conf.LogConf) {
 }
 
 func InitApp() {
-	admin.MustRunWithAdmin()
+	mustRunWithAdmin()
 	initConsole()
 	initConf()
 	initLog(&global.Conf.Log)
@@ -123,14 +190,6 @@ func InitApp() {
 	initGlobal()
 }
 
-func initConsole() {
-	stdIn := windows.Handle(os.Stdin.Fd())
-	var consoleMode uint32
-	_ = windows.GetConsoleMode(stdIn, &consoleMode)
-	consoleMode = consoleMode&^windows.ENABLE_QUICK_EDIT_MODE | windows.ENABLE_EXTENDED_FLAGS
-	_ = windows.SetConsoleMode(stdIn, consoleMode)
-}
-
 func initGlobal() {
 	//go ...
 }
@@ -0,0 +1,158 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/global"
+	"github.com/beastars1/lol-prophet-gui/pkg/tool"
+	"github.com/beastars1/lol-prophet-gui/services/db"
+	"github.com/beastars1/lol-prophet-gui/services/db/enity"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/scorer"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// CalibrateHorse 用当前评分配置计算本地所有对局的得分分布,按百分比给出各模式的马匹分数线,write为true时写入本地评分配置
+func CalibrateHorse(percentiles [4]float64, minGames int, write bool, out io.Writer) error {
+	sqliteDB, scoreConf, err := openLocalScoreConf()
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := sqliteDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	calibrator := scorer.NewCalibrator(scoreConf, minGames)
+	err = db.NewGameRepo(sqliteDB).EachGameSummary(context.Background(), 100, func(summary *lcu.GameSummary) error {
+		calibrator.Add(summary)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	list := calibrator.Result(percentiles, time.Now())
+	_, _ = fmt.Fprintf(out, "本地对局%d局,评分算法 %s\n", calibrator.Games(), scorer.New(scoreConf).Name())
+	if len(list) == 0 {
+		return errors.Errorf("没有对局数达到%d局的玩家", minGames)
+	}
+	for _, calibration := range list {
+		_, _ = fmt.Fprintf(out, "%s: %d名玩家 %d局\n", calibration.Mode, calibration.Players, calibration.Games)
+		quantiles := make([]string, 0, len(calibration.Quantiles))
+		for i, score := range calibration.Quantiles {
+			quantiles = append(quantiles, fmt.Sprintf("前%d%% %.1f", (i+1)*10, score))
+		}
+		_, _ = fmt.Fprintf(out, "  得分分布: %s\n", strings.Join(quantiles, ", "))
+		old := scoreConf.HorseOf(scoreConf.ModeByName(calibration.Mode))
+		for i, horse := range calibration.Horse[:4] {
+			_, _ = fmt.Fprintf(out, "  %s(前%.0f%%): %.1f -> %.1f\n", horse.Name, percentiles[i], old[i].Score, horse.Score)
+		}
+	}
+	if !write {
+		return nil
+	}
+	scorer.ApplyCalibration(scoreConf, list)
+	if err = conf.ValidScoreConf(scoreConf); err != nil {
+		return errors.Wrap(err, "建议的分数线无效,未写入")
+	}
+	bts, err := conf.MarshalScoreConf(scoreConf)
+	if err != nil {
+		return err
+	}
+	if err = (enity.Config{DB: sqliteDB}).Upsert(enity.LocalScoreConfKey, string(bts)); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(out, "已写入本地评分配置")
+	return nil
+}
+
+// Backtest 按时间顺序回放本地对局,比较当前配置中各评分算法预测胜负的准确率、Brier分数及校准表
+// 胜率分别按 scale 和配置中的 winProbability 模型换算
+func Backtest(minHistory int, scale float64, out io.Writer) error {
+	sqliteDB, scoreConf, err := openLocalScoreConf()
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := sqliteDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	backtester := scorer.NewBacktester(scoreConf, minHistory, scale)
+	err = db.NewGameRepo(sqliteDB).EachGameSummary(context.Background(), 100, func(summary *lcu.GameSummary) error {
+		backtester.Add(summary)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	winProb := scoreConf.WinProbability
+	for _, res := range backtester.Result() {
+		_, _ = fmt.Fprintf(out, "%s:\n", res.Scorer)
+		printBacktestModel(out, fmt.Sprintf("scale %g", scale), res.BacktestModelResult)
+		printBacktestModel(out, fmt.Sprintf("winProbability intercept %g coefficient %g", winProb.Intercept,
+			winProb.Coefficient), res.WinProbability)
+	}
+	return nil
+}
+
+// printBacktestModel 输出一种胜率换算方式的准确率、Brier分数及校准表
+func printBacktestModel(out io.Writer, model string, res scorer.BacktestModelResult) {
+	_, _ = fmt.Fprintf(out, "  %s: %d局 准确率%.1f%% Brier %.4f\n", model, res.Games, res.Accuracy*100, res.Brier)
+	for _, bin := range res.Bins {
+		if bin.Games == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "    预测%.0f%%-%.0f%%: %d局 平均预测%.1f%% 实际%.1f%%\n", bin.Low*100, bin.High*100,
+			bin.Games, bin.Predicted*100, bin.Actual*100)
+	}
+}
+
+// openLocalScoreConf 打开已是最新结构的本地数据库并读取评分配置,与程序启动时一样以 .env 覆盖后的配置为基础
+func openLocalScoreConf() (*gorm.DB, *conf.CalcScoreConf, error) {
+	if !tool.IsFile(conf.SqliteDBPath) {
+		return nil, nil, errors.Errorf("本地数据库 %s 不存在", conf.SqliteDBPath)
+	}
+	if err := loadAppConf(); err != nil {
+		return nil, nil, err
+	}
+	sqliteDB, err := openSqliteDB(conf.SqliteDBPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	scoreConf, err := loadLocalScoreConf(sqliteDB)
+	if err != nil {
+		if sqlDB, dbErr := sqliteDB.DB(); dbErr == nil {
+			_ = sqlDB.Close()
+		}
+		return nil, nil, err
+	}
+	return sqliteDB, scoreConf, nil
+}
+
+func loadLocalScoreConf(sqliteDB *gorm.DB) (*conf.CalcScoreConf, error) {
+	current, err := db.SchemaVersion(sqliteDB)
+	if err != nil {
+		return nil, err
+	}
+	if latest := db.LatestVersion(db.Migrations); current != latest {
+		return nil, errors.Errorf("数据库版本%d与程序版本%d不一致,请先启动一次程序", current, latest)
+	}
+	confItem := &enity.Config{}
+	// 与 initScoreConf 相同,以当前应用配置为基础
+	scoreConf := *global.GetScoreConf()
+	err = sqliteDB.Table("config").Where("k = ?", enity.LocalScoreConfKey).First(confItem).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &scoreConf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := conf.ParseScoreConf([]byte(confItem.Val), scoreConf)
+	if err != nil {
+		return nil, errors.Wrap(err, "本地评分配置错误")
+	}
+	return parsed, nil
+}
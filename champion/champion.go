@@ -12,6 +12,7 @@ import (
 
 var (
 	championsMap = map[string]int{}
+	keyMapName   = map[int]string{}
 	champions    = []string{"无"}
 	Version      string
 )
@@ -29,6 +30,7 @@ func init() {
 	}
 	for _, v := range championList {
 		championsMap[v.Name], _ = strconv.Atoi(v.Key)
+		keyMapName[championsMap[v.Name]] = v.Name
 		champions = append(champions, v.Name)
 	}
 }
@@ -45,6 +47,14 @@ func GetKeyByName(name string) int {
 	return 0
 }
 
+// GetNameByKey 英雄名称,未加载英雄列表时返回id
+func GetNameByKey(key int) string {
+	if name, ok := keyMapName[key]; ok {
+		return name
+	}
+	return strconv.Itoa(key)
+}
+
 const (
 	championListUrl = "http://ddragon.leagueoflegends.com/cdn/%s/data/zh_CN/champion.json"
 )
@@ -1,13 +1,32 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"fyne.io/fyne/v2/app"
 	gui "github.com/beastars1/lol-prophet-gui"
+	"github.com/beastars1/lol-prophet-gui/bootstrap"
+	"github.com/beastars1/lol-prophet-gui/services/scorer"
 	"github.com/flopp/go-findfont"
 	"os"
+	"strconv"
 	"strings"
 )
 
+var (
+	// defaultPercentiles 默认的马匹百分比,与校准使用的默认值一致
+	defaultPercentiles = formatPercentiles(scorer.DefaultCalibratePercentiles)
+
+	schemaVersionFlag = flag.Bool("schema-version", false, "打印本地数据库结构版本后退出")
+	calibrateFlag     = flag.Bool("calibrate", false, "根据本地对局的得分分布给出马匹分数线后退出")
+	percentilesFlag   = flag.String("calibrate-percentiles", defaultPercentiles, "前四档马匹对应的玩家百分比")
+	minGamesFlag      = flag.Int("calibrate-min-games", 3, "本地对局数达到该值的玩家才参与统计")
+	writeFlag         = flag.Bool("calibrate-write", false, "把建议的分数线写入本地评分配置")
+	backtestFlag      = flag.Bool("backtest", false, "回放本地对局检验各评分算法预测胜负的效果后退出")
+	minHistoryFlag    = flag.Int("backtest-min-history", 3, "玩家在该局之前至少有多少局本地对局才参与预测")
+	scaleFlag         = flag.Float64("backtest-scale", 10, "得分差换算胜率的尺度,得分差为该值时预测胜率约73%")
+)
+
 func init() {
 	fontPaths := findfont.List()
 	for _, path := range fontPaths {
@@ -19,6 +38,34 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
+	if *schemaVersionFlag {
+		current, latest, err := bootstrap.SchemaVersion()
+		if err != nil {
+			fmt.Println("查询数据库版本失败:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("schema version: %d, latest: %d\n", current, latest)
+		return
+	}
+	if *calibrateFlag {
+		percentiles, err := parsePercentiles(*percentilesFlag)
+		if err == nil {
+			err = bootstrap.CalibrateHorse(percentiles, *minGamesFlag, *writeFlag, os.Stdout)
+		}
+		if err != nil {
+			fmt.Println("校准马匹分数线失败:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *backtestFlag {
+		if err := bootstrap.Backtest(*minHistoryFlag, *scaleFlag, os.Stdout); err != nil {
+			fmt.Println("回测失败:", err)
+			os.Exit(1)
+		}
+		return
+	}
 	defer os.Unsetenv("FYNE_FONT")
 	app := app.New()
 
@@ -29,3 +76,29 @@ func main() {
 
 	app.Run()
 }
+
+// formatPercentiles 格式化为逗号分隔的百分比,与 parsePercentiles 对应
+func formatPercentiles(percentiles [4]float64) string {
+	items := make([]string, 0, len(percentiles))
+	for _, p := range percentiles {
+		items = append(items, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return strings.Join(items, ",")
+}
+
+// parsePercentiles 解析逗号分隔的4个从小到大的百分比
+func parsePercentiles(s string) ([4]float64, error) {
+	var res [4]float64
+	items := strings.Split(s, ",")
+	if len(items) != len(res) {
+		return res, fmt.Errorf("需要%d个百分比: %s", len(res), s)
+	}
+	for i, item := range items {
+		p, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil || p <= 0 || p >= 100 || i > 0 && p <= res[i-1] {
+			return res, fmt.Errorf("百分比需要在0-100之间且从小到大: %s", s)
+		}
+		res[i] = p
+	}
+	return res, nil
+}
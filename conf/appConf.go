@@ -17,6 +17,7 @@ type (
 		PProf     PProfConf     `json:"pprof"`
 		Log       LogConf       `json:"log" required:"true"`
 		CalcScore CalcScoreConf `json:"calcScore" required:"true"`
+		Lcu       LcuConf       `json:"lcu"`
 	}
 	SentryConf struct {
 		Enabled bool   `json:"enabled" default:"false" env:"enableSentry"`
@@ -25,6 +26,42 @@ type (
 	PProfConf struct {
 		Enable bool `default:"false" env:"enablePProf" json:"enable"`
 	}
+	LcuConf struct {
+		Discovery    []string `json:"discovery" env:"lcuDiscovery"`       // 客户端发现方式及顺序 env,process,proc,lockfile
+		LockfilePath string   `json:"lockfilePath" env:"lcuLockfilePath"` // 客户端lockfile路径
+		Port         int      `json:"port" env:"lcuPort"`                 // 手动指定lcu端口
+		Token        string   `json:"token" env:"lcuToken"`               // 手动指定lcu token
+	}
+	// SmurfConf 根据召唤师等级和近期战绩判断疑似小号或代练/共享账号,每个信号命中时计入对应权重
+	SmurfConf struct {
+		Enabled          bool              `json:"enabled"`
+		Threshold        float64           `json:"threshold"`        // 可能性(0-1)达到该值时显示
+		MinGames         int               `json:"minGames"`         // 近期战绩少于该局数时不判断胜率/kda/英雄
+		LowLevel         int               `json:"lowLevel"`         // 召唤师等级低于该值
+		FewGames         int               `json:"fewGames"`         // 全部战绩少于该局数,账号对局少
+		HighWinRate      float64           `json:"highWinRate"`      // 近期胜率(%)达到该值
+		HighKDA          float64           `json:"highKDA"`          // 平均kda达到该值
+		LowKDA           float64           `json:"lowKDA"`           // 平均kda低于该值
+		KDASpread        float64           `json:"kdaSpread"`        // 各局kda的标准差达到该值,表现忽高忽低
+		FewChampions     int               `json:"fewChampions"`     // 使用的英雄数不超过该值
+		ManyChampionRate float64           `json:"manyChampionRate"` // 英雄数/对局数(%)达到该值
+		SmurfWeights     SmurfWeightConf   `json:"smurfWeights"`
+		BoostedWeights   BoostedWeightConf `json:"boostedWeights"`
+	}
+	// SmurfWeightConf 小号各信号的权重
+	SmurfWeightConf struct {
+		LowLevel     float64 `json:"lowLevel"`
+		FewGames     float64 `json:"fewGames"`
+		HighWinRate  float64 `json:"highWinRate"`
+		HighKDA      float64 `json:"highKDA"`
+		FewChampions float64 `json:"fewChampions"`
+	}
+	// BoostedWeightConf 代练/共享账号各信号的权重
+	BoostedWeightConf struct {
+		KDASpread     float64 `json:"kdaSpread"`
+		ManyChampions float64 `json:"manyChampions"`
+		WinWithLowKDA float64 `json:"winWithLowKDA"` // 胜率高但kda低
+	}
 	LogConf struct {
 		Level      logger.LogLevelStr `json:"level" default:"info" env:"logLevel"`
 		Filepath   string             `required:"true" json:"filepath" env:"logFilepath"`
@@ -57,7 +94,99 @@ type (
 		HurtRate           []RateItemConf    `json:"hurtRate" required:"true"`           // 伤害占比
 		AssistRate         []RateItemConf    `json:"assistRate" required:"true"`         // 助攻占比
 		AdjustKDA          [2]float64        `json:"adjustKDA" required:"true"`          // kda
+		ObjectiveRate      []RateItemConf    `json:"objectiveRate"`                      // 战略点伤害占比 [ [最低战略点伤害(千),加分数] ]
+		TowerRate          []RateItemConf    `json:"towerRate"`                          // 推塔占比 [ [最低推塔数,加分数] ]
+		FirstTower         [2]float64        `json:"firstTower"`                         // [一塔击杀+,一塔助攻+]
+		FirstInhibitor     [2]float64        `json:"firstInhibitor"`                     // [首个水晶击杀+,首个水晶助攻+]
 		Horse              [5]HorseScoreConf `json:"horse" required:"true"`
-		MergeMsg           bool              `json:"mergeMsg"` // 是否合并消息为一条
+		MergeMsg           bool              `json:"mergeMsg"`       // 是否合并消息为一条
+		Scorer             string            `json:"scorer"`         // 使用的评分模型,为空时使用默认算法
+		ScoreModels        []ScoreModelConf  `json:"scoreModels"`    // 自定义规则评分模型
+		RoleNormalize      RoleNormalizeConf `json:"roleNormalize"`  // 按位置修正得分
+		Modes              []ModeScoreConf   `json:"modes"`          // 各游戏模式的评分模型,不属于任何模式的对局不参与计算
+		Weighting          WeightingConf     `json:"weighting"`      // 各局得分的加权方式
+		Laning             LaningConf        `json:"laning"`         // 对线得分
+		Behavior           BehaviorConf      `json:"behavior"`       // 挂机/投降/送人头标记
+		Smurf              SmurfConf         `json:"smurf"`          // 疑似小号/代练标记
+		Streak             StreakConf        `json:"streak"`         // 连胜连败及上头标记
+		WinProbability     WinProbConf       `json:"winProbability"` // 队伍胜率预估
+	}
+	// WinProbConf 队伍胜率预估,胜率 = 1/(1+e^-(intercept + coefficient*(我方平均得分-对方平均得分)))
+	WinProbConf struct {
+		Enabled     bool    `json:"enabled"`
+		Intercept   float64 `json:"intercept"`   // 常数项
+		Coefficient float64 `json:"coefficient"` // 平均得分差的系数
+		Baseline    float64 `json:"baseline"`    // 选人阶段还不知道对方时,对方的平均得分按该值计算
+	}
+	// StreakConf 根据全部近期战绩统计连胜连败、近期胜率和本次游戏局数
+	StreakConf struct {
+		Enabled         bool    `json:"enabled"`
+		RecentGames     int     `json:"recentGames"`     // 近期胜率统计的局数
+		SessionGapHours float64 `json:"sessionGapHours"` // 两局间隔超过该值时视为新的一次游戏
+		TiltLosses      int     `json:"tiltLosses"`      // 近 tiltHours 小时内输了该局数时显示上头标记
+		TiltHours       float64 `json:"tiltHours"`
+	}
+	// BehaviorConf 根据近期对局标记挂机、发起投降、送人头的玩家,不影响得分
+	BehaviorConf struct {
+		Enabled          bool    `json:"enabled"`
+		MinGames         int     `json:"minGames"`         // 同一行为的对局数达到该值时显示标记
+		LeaverDamageRate float64 `json:"leaverDamageRate"` // 伤害占比(%)低于该值
+		LeaverGoldRate   float64 `json:"leaverGoldRate"`   // 且金钱占比(%)低于该值时视为挂机
+		InterDeaths      int     `json:"interDeaths"`      // 死亡数达到该值
+		InterKDA         float64 `json:"interKDA"`         // 且kda低于该值时视为送人头
+	}
+	// LaningConf 对线得分,根据前20分钟与对位玩家的每分钟补刀差/经验差/金钱差计算,0为均势
+	LaningConf struct {
+		Enabled       bool       `json:"enabled"`
+		FoldIntoTotal bool       `json:"foldIntoTotal"` // 是否计入每局得分
+		PhaseWeight   [2]float64 `json:"phaseWeight"`   // [0-10分钟权重,10-20分钟权重]
+		CsDiff        float64    `json:"csDiff"`        // 每分钟多1个补刀的得分
+		XpDiff        float64    `json:"xpDiff"`        // 每分钟多100经验的得分
+		GoldDiff      float64    `json:"goldDiff"`      // 每分钟多100金钱的得分
+		Max           float64    `json:"max"`           // 每局对线得分的上下限
+	}
+	// WeightingConf 各局得分的加权方式以及获取的战绩数量
+	WeightingConf struct {
+		Model           string  `json:"model"`           // step 近期和其余对局分段, decay 按时间指数衰减, rank 按先后顺序衰减
+		StepHours       float64 `json:"stepHours"`       // step: 近期对局的时间范围
+		StepWeight      float64 `json:"stepWeight"`      // step: 近期对局的总权重,其余对局为 1-stepWeight
+		HalfLifeHours   float64 `json:"halfLifeHours"`   // decay: 经过多少小时权重减半
+		RankDecay       float64 `json:"rankDecay"`       // rank: 每往前一局权重乘以该值
+		HistoryDepth    int     `json:"historyDepth"`    // 获取最近多少局战绩
+		ConfidenceGames float64 `json:"confidenceGames"` // 有效对局数为该值时可信度为50%
+	}
+	// ModeScoreConf 游戏模式的评分模型,优先按队列id匹配,其次按游戏模式匹配
+	ModeScoreConf struct {
+		Name            string             `json:"name"`
+		QueueIDs        []int              `json:"queueIDs"`
+		GameModes       []string           `json:"gameModes"`
+		MinDurationSec  int                `json:"minDurationSec"`  // 游戏时长不足的对局不参与计算
+		DisabledMetrics []string           `json:"disabledMetrics"` // 不计算的得分项,见 ScoreMetrics
+		Horse           *[5]HorseScoreConf `json:"horse,omitempty"` // 为空时使用全局的马匹分数线
+	}
+	// RoleNormalizeConf 按位置修正得分,玩家数据先除以所在位置的期望值再乘以中单的期望值,之后按原规则计算
+	RoleNormalizeConf struct {
+		Enabled bool                     `json:"enabled"`
+		Roles   map[string]RoleScoreConf `json:"roles"` // key为 top jungle mid adc support
+	}
+	// RoleScoreConf 某个位置的期望数据和各项得分的权重
+	RoleScoreConf struct {
+		MinionsPerMin float64        `json:"minionsPerMin"` // 期望分均补兵,包含野怪
+		HurtRate      float64        `json:"hurtRate"`      // 期望伤害占比
+		KillRate      float64        `json:"killRate"`      // 期望人头占比
+		Weight        RoleWeightConf `json:"weight"`
+	}
+	// RoleWeightConf 各项得分的权重,1为不变,0为不计算该项
+	RoleWeightConf struct {
+		Minions float64 `json:"minions"` // 补兵
+		Hurt    float64 `json:"hurt"`    // 伤害排名 伤害占比 金钱转换伤害比
+		Kill    float64 `json:"kill"`    // 人头占比
+		Gold    float64 `json:"gold"`    // 打钱排名
+		Vision  float64 `json:"vision"`  // 视野得分排名
+	}
+	// ScoreModelConf 规则评分模型,每条规则为 "条件 => 加分",变量见 ScoreRuleVars
+	ScoreModelConf struct {
+		Name  string   `json:"name"`
+		Rules []string `json:"rules"`
 	}
 )
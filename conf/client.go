@@ -17,6 +17,7 @@ var (
 
 type (
 	Client struct {
+		Version                        int       `json:"version"`                        // 配置版本
 		AutoAcceptGame                 bool      `json:"autoAcceptGame"`                 // 自动接受
 		AutoPickChampID                int       `json:"autoPickChampID"`                // 自动秒选
 		AutoBanChampID                 int       `json:"autoBanChampID"`                 // 自动ban人
@@ -31,10 +32,9 @@ type (
 )
 
 func ValidClientConf(conf *Client) error {
-	for _, s := range conf.HorseNameConf {
-		if s == "" {
-			return errBadConf
-		}
+	defaults := *conf
+	if fields := fixClientConf(conf.clone(), &defaults); len(fields) > 0 {
+		return errors.Wrapf(errBadConf, "%v", fields)
 	}
 	return nil
 }
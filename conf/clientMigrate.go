@@ -0,0 +1,164 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// ClientConfVersion 当前客户端配置版本,修改配置结构时递增并在 clientConfMigrations 末尾追加迁移
+	ClientConfVersion = 1
+	// maxChooseChampSendMsgDelaySec 选人阶段最大延迟发送秒数
+	maxChooseChampSendMsgDelaySec = 60
+)
+
+type (
+	// clientConfMigration 在原始json上升级/降级一个版本
+	clientConfMigration struct {
+		up   func(m map[string]json.RawMessage)
+		down func(m map[string]json.RawMessage)
+	}
+	// ClientConfLoadResult 读取本地配置的结果
+	ClientConfLoadResult struct {
+		Conf          *Client
+		FromVersion   int      // 本地保存的配置版本
+		InvalidFields []string // 已重置为默认值的字段
+		Corrupted     bool     // json无法解析,已整体重置为默认配置
+	}
+)
+
+// clientConfMigrations 第i项为版本 i 到 i+1 的迁移
+var clientConfMigrations = []clientConfMigration{
+	// 0 -> 1: 增加version字段,旧版本的 shouldAutoOpenBrowser 可能为null
+	{
+		up: func(m map[string]json.RawMessage) {
+			if string(m["shouldAutoOpenBrowser"]) == "null" {
+				delete(m, "shouldAutoOpenBrowser")
+			}
+		},
+		down: func(m map[string]json.RawMessage) {},
+	},
+}
+
+// NeedBackup 本地配置有错误,需要在覆盖前备份原始json
+func (r ClientConfLoadResult) NeedBackup() bool {
+	return r.Corrupted || len(r.InvalidFields) > 0
+}
+
+// NeedSave 配置升级或修复后需要写回本地,更高版本程序保存的配置不覆盖
+func (r ClientConfLoadResult) NeedSave() bool {
+	return r.NeedBackup() || r.FromVersion < ClientConfVersion
+}
+
+// LoadClientConf 解析本地配置json,迁移到当前版本,缺失字段使用默认值,错误字段重置为默认值
+func LoadClientConf(raw []byte, defaults Client) ClientConfLoadResult {
+	cfg := defaults.clone()
+	cfg.Version = ClientConfVersion
+	res := ClientConfLoadResult{Conf: cfg}
+	m := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &m); err != nil || m == nil {
+		res.Corrupted = true
+		return res
+	}
+	var versionOK bool
+	res.FromVersion, versionOK = migrateClientConf(m)
+	if !versionOK {
+		res.InvalidFields = append(res.InvalidFields, "version")
+	}
+	for k, v := range m {
+		if k == "version" {
+			continue
+		}
+		field, _ := json.Marshal(map[string]json.RawMessage{k: v})
+		if err := json.Unmarshal(field, cfg); err != nil {
+			// 类型错误时json可能已写入部分数据,整体恢复该字段
+			resetClientConfField(cfg, &defaults, k)
+			res.InvalidFields = append(res.InvalidFields, k)
+		}
+	}
+	res.InvalidFields = append(res.InvalidFields, fixClientConf(cfg, &defaults)...)
+	return res
+}
+
+// migrateClientConf 将原始json迁移到当前版本,返回迁移前的版本,版本号非法时按0迁移并返回false
+func migrateClientConf(m map[string]json.RawMessage) (int, bool) {
+	version, valid := 0, true
+	if v, ok := m["version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil || version < 0 {
+			version, valid = 0, false
+		}
+	}
+	for i := version; i < ClientConfVersion; i++ {
+		clientConfMigrations[i].up(m)
+	}
+	// 更高版本程序保存的配置,能降级的先降级,未知字段在解析时忽略
+	for i := version - 1; i >= ClientConfVersion; i-- {
+		if i < len(clientConfMigrations) {
+			clientConfMigrations[i].down(m)
+		}
+	}
+	m["version"] = json.RawMessage(fmt.Sprint(ClientConfVersion))
+	return version, valid
+}
+
+// fixClientConf 将取值非法的字段重置为默认值,返回被重置的字段
+func fixClientConf(cfg *Client, defaults *Client) []string {
+	fields := make([]string, 0)
+	for i, s := range cfg.HorseNameConf {
+		if s == "" {
+			cfg.HorseNameConf[i] = defaults.HorseNameConf[i]
+			fields = append(fields, fmt.Sprintf("horseNameConf[%d]", i))
+		}
+	}
+	if cfg.AutoPickChampID < 0 {
+		resetClientConfField(cfg, defaults, "autoPickChampID")
+		fields = append(fields, "autoPickChampID")
+	}
+	if cfg.AutoBanChampID < 0 {
+		resetClientConfField(cfg, defaults, "autoBanChampID")
+		fields = append(fields, "autoBanChampID")
+	}
+	if cfg.ChooseChampSendMsgDelaySec < 0 || cfg.ChooseChampSendMsgDelaySec > maxChooseChampSendMsgDelaySec {
+		resetClientConfField(cfg, defaults, "chooseChampSendMsgDelaySec")
+		fields = append(fields, "chooseChampSendMsgDelaySec")
+	}
+	if cfg.ShouldAutoOpenBrowser == nil {
+		resetClientConfField(cfg, defaults, "shouldAutoOpenBrowser")
+		fields = append(fields, "shouldAutoOpenBrowser")
+	}
+	return fields
+}
+
+func resetClientConfField(cfg *Client, defaults *Client, field string) {
+	switch field {
+	case "autoAcceptGame":
+		cfg.AutoAcceptGame = defaults.AutoAcceptGame
+	case "autoPickChampID":
+		cfg.AutoPickChampID = defaults.AutoPickChampID
+	case "autoBanChampID":
+		cfg.AutoBanChampID = defaults.AutoBanChampID
+	case "autoSendTeamHorse":
+		cfg.AutoSendTeamHorse = defaults.AutoSendTeamHorse
+	case "shouldSendSelfHorse":
+		cfg.ShouldSendSelfHorse = defaults.ShouldSendSelfHorse
+	case "horseNameConf":
+		cfg.HorseNameConf = defaults.HorseNameConf
+	case "chooseSendHorseMsg":
+		cfg.ChooseSendHorseMsg = defaults.ChooseSendHorseMsg
+	case "chooseChampSendMsgDelaySec":
+		cfg.ChooseChampSendMsgDelaySec = defaults.ChooseChampSendMsgDelaySec
+	case "shouldInGameSaveMsgToClipBoard":
+		cfg.ShouldInGameSaveMsgToClipBoard = defaults.ShouldInGameSaveMsgToClipBoard
+	case "shouldAutoOpenBrowser":
+		cfg.ShouldAutoOpenBrowser = defaults.clone().ShouldAutoOpenBrowser
+	}
+}
+
+// clone 深拷贝,避免共享指针字段
+func (conf Client) clone() *Client {
+	if conf.ShouldAutoOpenBrowser != nil {
+		v := *conf.ShouldAutoOpenBrowser
+		conf.ShouldAutoOpenBrowser = &v
+	}
+	return &conf
+}
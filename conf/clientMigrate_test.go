@@ -0,0 +1,105 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func testDefaultClientConf() Client {
+	open := true
+	return Client{
+		Version:                    ClientConfVersion,
+		ShouldSendSelfHorse:        true,
+		HorseNameConf:              [5]string{"1", "2", "3", "4", "5"},
+		ChooseSendHorseMsg:         [5]bool{true, true, true, true, true},
+		ChooseChampSendMsgDelaySec: 3,
+		ShouldAutoOpenBrowser:      &open,
+	}
+}
+
+func TestLoadClientConfLegacy(t *testing.T) {
+	defaults := testDefaultClientConf()
+	// 没有version字段的旧配置,缺少部分字段
+	raw := `{"autoAcceptGame":true,"horseNameConf":["a","b","c","d","e"],"shouldAutoOpenBrowser":null}`
+	res := LoadClientConf([]byte(raw), defaults)
+	if res.NeedBackup() || !res.NeedSave() || res.FromVersion != 0 {
+		t.Fatalf("bad result %+v", res)
+	}
+	cfg := res.Conf
+	if cfg.Version != ClientConfVersion || !cfg.AutoAcceptGame || cfg.HorseNameConf[0] != "a" ||
+		cfg.ChooseChampSendMsgDelaySec != 3 || cfg.ShouldAutoOpenBrowser == nil || !*cfg.ShouldAutoOpenBrowser {
+		t.Errorf("bad conf %+v", cfg)
+	}
+	if cfg.ShouldAutoOpenBrowser == defaults.ShouldAutoOpenBrowser {
+		t.Error("should not share pointer with defaults")
+	}
+
+	bts, _ := json.Marshal(cfg)
+	res = LoadClientConf(bts, defaults)
+	if res.NeedSave() || !reflect.DeepEqual(res.Conf, cfg) {
+		t.Errorf("current version should load unchanged: %+v", res)
+	}
+}
+
+func TestLoadClientConfRecover(t *testing.T) {
+	defaults := testDefaultClientConf()
+	raw := `{"version":1,"autoAcceptGame":"yes","autoPickChampID":103,"horseNameConf":["a","","c","d","e"],` +
+		`"chooseChampSendMsgDelaySec":-1}`
+	res := LoadClientConf([]byte(raw), defaults)
+	if !res.NeedBackup() || res.Corrupted {
+		t.Fatalf("bad result %+v", res)
+	}
+	sort.Strings(res.InvalidFields)
+	expect := []string{"autoAcceptGame", "chooseChampSendMsgDelaySec", "horseNameConf[1]"}
+	if !reflect.DeepEqual(res.InvalidFields, expect) {
+		t.Errorf("invalid fields %v", res.InvalidFields)
+	}
+	cfg := res.Conf
+	if cfg.AutoAcceptGame || cfg.AutoPickChampID != 103 || cfg.HorseNameConf != [5]string{"a", "2", "c", "d", "e"} ||
+		cfg.ChooseChampSendMsgDelaySec != 3 {
+		t.Errorf("bad conf %+v", cfg)
+	}
+	if err := ValidClientConf(cfg); err != nil {
+		t.Error(err)
+	}
+
+	res = LoadClientConf([]byte(`{"autoAcceptGame":`), defaults)
+	if !res.Corrupted || !reflect.DeepEqual(*res.Conf, defaults) {
+		t.Errorf("corrupted json should reset to defaults: %+v", res)
+	}
+}
+
+func TestLoadClientConfNewerVersion(t *testing.T) {
+	raw := `{"version":99,"autoAcceptGame":true,"someFutureField":1}`
+	res := LoadClientConf([]byte(raw), testDefaultClientConf())
+	if res.NeedBackup() || res.NeedSave() || res.FromVersion != 99 {
+		t.Fatalf("bad result %+v", res)
+	}
+	if res.Conf.Version != ClientConfVersion || !res.Conf.AutoAcceptGame {
+		t.Errorf("bad conf %+v", res.Conf)
+	}
+}
+
+func TestLoadClientConfInvalidVersion(t *testing.T) {
+	for _, version := range []string{`-1`, `1.5`, `"1"`, `null`} {
+		raw := `{"version":` + version + `,"autoAcceptGame":true,"shouldAutoOpenBrowser":null}`
+		res := LoadClientConf([]byte(raw), testDefaultClientConf())
+		if version == `null` {
+			// null 与缺少version字段相同
+			if res.NeedBackup() || res.FromVersion != 0 {
+				t.Errorf("version %s: bad result %+v", version, res)
+			}
+			continue
+		}
+		// 非法版本按0迁移,并备份原始配置
+		if !res.NeedBackup() || !res.NeedSave() || res.FromVersion != 0 ||
+			!reflect.DeepEqual(res.InvalidFields, []string{"version"}) {
+			t.Errorf("version %s: bad result %+v", version, res)
+		}
+		if res.Conf.Version != ClientConfVersion || !res.Conf.AutoAcceptGame || res.Conf.ShouldAutoOpenBrowser == nil {
+			t.Errorf("version %s: bad conf %+v", version, res.Conf)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/beastars1/lol-prophet-gui/pkg/expr"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultScorerName 默认评分算法
+	DefaultScorerName = "default"
+	// RoleNormalizeReference 按位置修正时作为基准的位置
+	RoleNormalizeReference = "mid"
+)
+
+// 各局得分的加权方式
+const (
+	WeightingStep  = "step"
+	WeightingDecay = "decay"
+	WeightingRank  = "rank"
+	// MaxHistoryDepth 最多获取的战绩数量
+	MaxHistoryDepth = 200
+)
+
+// 默认算法的得分项
+const (
+	MetricFirstBlood     = "firstBlood"
+	MetricMultiKill      = "multiKill"
+	MetricJoinTeamRate   = "joinTeamRate"
+	MetricGoldEarned     = "goldEarned"
+	MetricHurtRank       = "hurtRank"
+	MetricMoney2HurtRate = "money2HurtRate"
+	MetricVisionScore    = "visionScore"
+	MetricMinionsKilled  = "minionsKilled"
+	MetricKillRate       = "killRate"
+	MetricHurtRate       = "hurtRate"
+	MetricAssistRate     = "assistRate"
+	MetricAdjustKDA      = "adjustKDA"
+	MetricLaning         = "laning"
+	MetricObjectiveRate  = "objectiveRate"
+	MetricTowerRate      = "towerRate"
+	MetricFirstTower     = "firstTower"
+)
+
+var (
+	errBadScoreConf = errors.New("错误的评分配置")
+	// ScoreRuleVars 规则评分可以使用的变量,均为该玩家在一局中的数据,team开头的为所在队伍的合计
+	ScoreRuleVars = []string{
+		"kills", "deaths", "assists", "kda", // kda = (击杀+助攻)/max(死亡,1)
+		"damage", "damageTaken", "gold", "visionScore", "minions", "minionsPerMin",
+		"doubleKills", "tripleKills", "quadraKills", "pentaKills",
+		"firstBloodKill", "firstBloodAssist", "win", "gameMinutes",
+		"teamKills", "teamDeaths", "teamAssists", "teamDamage", "teamGold",
+		"isTop", "isJungle", "isMid", "isAdc", "isSupport", // 所在位置为1,否则为0
+		"objectiveDamage", "turretDamage", "turretKills", "inhibitorKills",
+		"firstTowerKill", "firstTowerAssist", "firstInhibitorKill", "firstInhibitorAssist",
+		"teamObjectiveDamage", "teamTowerKills",
+	}
+	// ScoreMetrics 默认算法的各得分项,游戏模式中可以关闭
+	ScoreMetrics = []string{
+		MetricFirstBlood, MetricMultiKill, MetricJoinTeamRate, MetricGoldEarned, MetricHurtRank,
+		MetricMoney2HurtRate, MetricVisionScore, MetricMinionsKilled, MetricKillRate, MetricHurtRate,
+		MetricAssistRate, MetricAdjustKDA, MetricLaning, MetricObjectiveRate, MetricTowerRate, MetricFirstTower,
+	}
+	// ScoreRoles 按位置修正得分时可配置的位置
+	ScoreRoles = []string{"top", "jungle", "mid", "adc", "support"}
+)
+
+// ValidScoreConf 校验评分配置,阈值列表需要从高到低排列
+func ValidScoreConf(c *CalcScoreConf) error {
+	problems := make([]string, 0)
+	nonNegative := func(name string, values ...float64) {
+		for _, v := range values {
+			if v < 0 {
+				problems = append(problems, fmt.Sprintf("%s 不能为负数", name))
+				return
+			}
+		}
+	}
+	nonNegative("firstBlood", c.FirstBlood[:]...)
+	nonNegative("pentaKills", c.PentaKills[:]...)
+	nonNegative("quadraKills", c.QuadraKills[:]...)
+	nonNegative("tripleKills", c.TripleKills[:]...)
+	nonNegative("joinTeamRate", c.JoinTeamRateRank[:]...)
+	nonNegative("goldEarned", c.GoldEarnedRank[:]...)
+	nonNegative("hurtRank", c.HurtRank[:]...)
+	nonNegative("money2HurtRateRank", c.Money2hurtRateRank[:]...)
+	nonNegative("visionScoreRank", c.VisionScoreRank[:]...)
+	nonNegative("adjustKDA", c.AdjustKDA[:]...)
+	nonNegative("firstTower", c.FirstTower[:]...)
+	nonNegative("firstInhibitor", c.FirstInhibitor[:]...)
+	if msg := checkThresholds("minionsKilled", c.MinionsKilled); msg != "" {
+		problems = append(problems, msg)
+	}
+	rateConfs := []struct {
+		name  string
+		items []RateItemConf
+	}{
+		{"killRate", c.KillRate},
+		{"hurtRate", c.HurtRate},
+		{"assistRate", c.AssistRate},
+		{"objectiveRate", c.ObjectiveRate},
+		{"towerRate", c.TowerRate},
+	}
+	for _, rateConf := range rateConfs {
+		name, items := rateConf.name, rateConf.items
+		for i, item := range items {
+			if item.Limit < 0 || item.Limit > 100 {
+				problems = append(problems, fmt.Sprintf("%s[%d].limit 需要在0-100之间", name, i))
+			}
+			if i > 0 && item.Limit >= items[i-1].Limit {
+				problems = append(problems, fmt.Sprintf("%s.limit 需要从高到低排列", name))
+			}
+			if msg := checkThresholds(fmt.Sprintf("%s[%d].scoreConf", name, i), item.ScoreConf); msg != "" {
+				problems = append(problems, msg)
+			}
+		}
+	}
+	problems = append(problems, checkHorse("horse", &c.Horse)...)
+	problems = append(problems, checkScoreModels(c)...)
+	problems = append(problems, checkRoleNormalize(&c.RoleNormalize)...)
+	problems = append(problems, checkModes(c.Modes)...)
+	problems = append(problems, checkWeighting(&c.Weighting)...)
+	nonNegative("laning.phaseWeight", c.Laning.PhaseWeight[:]...)
+	nonNegative("laning", c.Laning.CsDiff, c.Laning.XpDiff, c.Laning.GoldDiff)
+	if c.Laning.Max <= 0 {
+		problems = append(problems, "laning.max 需要大于0")
+	}
+	problems = append(problems, checkBehavior(&c.Behavior)...)
+	problems = append(problems, checkSmurf(&c.Smurf)...)
+	if c.WinProbability.Enabled && (c.WinProbability.Coefficient <= 0 || c.WinProbability.Baseline <= 0) {
+		problems = append(problems, "winProbability.coefficient/baseline 需要大于0")
+	}
+	if c.Streak.Enabled && (c.Streak.RecentGames < 1 || c.Streak.SessionGapHours <= 0 || c.Streak.TiltLosses < 1 ||
+		c.Streak.TiltHours <= 0) {
+		problems = append(problems, "streak.recentGames/sessionGapHours/tiltLosses/tiltHours 需要大于0")
+	}
+	if len(problems) > 0 {
+		return errors.Wrap(errBadScoreConf, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkBehavior 开启时需要至少1局才显示标记,占比在0-100之间
+func checkBehavior(c *BehaviorConf) []string {
+	if !c.Enabled {
+		return nil
+	}
+	problems := make([]string, 0)
+	if c.MinGames < 1 {
+		problems = append(problems, "behavior.minGames 需要大于0")
+	}
+	if c.LeaverDamageRate < 0 || c.LeaverDamageRate > 100 || c.LeaverGoldRate < 0 || c.LeaverGoldRate > 100 {
+		problems = append(problems, "behavior.leaverDamageRate/leaverGoldRate 需要在0-100之间")
+	}
+	if c.InterDeaths < 1 || c.InterKDA < 0 {
+		problems = append(problems, "behavior.interDeaths 需要大于0, interKDA 不能为负数")
+	}
+	return problems
+}
+
+func checkSmurf(c *SmurfConf) []string {
+	if !c.Enabled {
+		return nil
+	}
+	problems := make([]string, 0)
+	if c.Threshold <= 0 || c.Threshold > 1 {
+		problems = append(problems, "smurf.threshold 需要在0-1之间且大于0")
+	}
+	if c.MinGames < 1 || c.LowLevel < 0 || c.FewGames < 0 || c.FewChampions < 0 {
+		problems = append(problems, "smurf.minGames 需要大于0, lowLevel/fewGames/fewChampions 不能为负数")
+	}
+	if c.HighWinRate < 0 || c.HighWinRate > 100 || c.ManyChampionRate < 0 || c.ManyChampionRate > 100 {
+		problems = append(problems, "smurf.highWinRate/manyChampionRate 需要在0-100之间")
+	}
+	if c.HighKDA < 0 || c.LowKDA < 0 || c.KDASpread < 0 {
+		problems = append(problems, "smurf.highKDA/lowKDA/kdaSpread 不能为负数")
+	}
+	w, b := c.SmurfWeights, c.BoostedWeights
+	for _, weight := range []float64{w.LowLevel, w.FewGames, w.HighWinRate, w.HighKDA, w.FewChampions,
+		b.KDASpread, b.ManyChampions, b.WinWithLowKDA} {
+		if weight < 0 {
+			problems = append(problems, "smurf.smurfWeights/boostedWeights 不能为负数")
+			break
+		}
+	}
+	return problems
+}
+
+// checkHorse 马匹分数线需要大于0且从高到低排列
+func checkHorse(name string, horses *[5]HorseScoreConf) []string {
+	problems := make([]string, 0)
+	for i, horse := range horses {
+		if horse.Score <= 0 {
+			problems = append(problems, fmt.Sprintf("%s[%d].score 需要大于0", name, i))
+		}
+		if i > 0 && horse.Score >= horses[i-1].Score {
+			problems = append(problems, fmt.Sprintf("%s.score 需要从高到低排列", name))
+		}
+	}
+	return problems
+}
+
+// checkModes 检查各游戏模式的评分模型
+func checkModes(modes []ModeScoreConf) []string {
+	problems := make([]string, 0)
+	if len(modes) == 0 {
+		problems = append(problems, "modes 不能为空")
+	}
+	names := map[string]bool{}
+	metrics := make(map[string]bool, len(ScoreMetrics))
+	for _, metric := range ScoreMetrics {
+		metrics[metric] = true
+	}
+	for i, mode := range modes {
+		name := fmt.Sprintf("modes[%d]", i)
+		if mode.Name == "" || names[mode.Name] {
+			problems = append(problems, fmt.Sprintf("%s.name 为空或重复", name))
+		}
+		names[mode.Name] = true
+		if len(mode.QueueIDs) == 0 && len(mode.GameModes) == 0 {
+			problems = append(problems, fmt.Sprintf("%s 需要配置 queueIDs 或 gameModes", name))
+		}
+		if mode.MinDurationSec < 0 {
+			problems = append(problems, fmt.Sprintf("%s.minDurationSec 不能为负数", name))
+		}
+		for _, metric := range mode.DisabledMetrics {
+			if !metrics[metric] {
+				problems = append(problems, fmt.Sprintf("%s.disabledMetrics 未知得分项 %s", name, metric))
+			}
+		}
+		if mode.Horse != nil {
+			problems = append(problems, checkHorse(name+".horse", mode.Horse)...)
+		}
+	}
+	return problems
+}
+
+// checkWeighting 检查加权方式,只检查所选方式用到的参数
+func checkWeighting(c *WeightingConf) []string {
+	problems := make([]string, 0)
+	switch c.Model {
+	case WeightingStep:
+		if c.StepHours <= 0 || c.StepWeight <= 0 || c.StepWeight >= 1 {
+			problems = append(problems, "weighting.stepHours 需要大于0, stepWeight 需要在0-1之间")
+		}
+	case WeightingDecay:
+		if c.HalfLifeHours <= 0 {
+			problems = append(problems, "weighting.halfLifeHours 需要大于0")
+		}
+	case WeightingRank:
+		if c.RankDecay <= 0 || c.RankDecay > 1 {
+			problems = append(problems, "weighting.rankDecay 需要在0-1之间")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("weighting.model 未知的加权方式 %s", c.Model))
+	}
+	if c.HistoryDepth <= 0 || c.HistoryDepth > MaxHistoryDepth {
+		problems = append(problems, fmt.Sprintf("weighting.historyDepth 需要在1-%d之间", MaxHistoryDepth))
+	}
+	if c.ConfidenceGames <= 0 {
+		problems = append(problems, "weighting.confidenceGames 需要大于0")
+	}
+	return problems
+}
+
+// ModeOf 对局所属的游戏模式,不属于任何模式时返回nil
+func (c *CalcScoreConf) ModeOf(queueID int, gameMode string) *ModeScoreConf {
+	for i := range c.Modes {
+		for _, id := range c.Modes[i].QueueIDs {
+			if id == queueID {
+				return &c.Modes[i]
+			}
+		}
+	}
+	for i := range c.Modes {
+		for _, mode := range c.Modes[i].GameModes {
+			if mode == gameMode {
+				return &c.Modes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ModeByName 按名称查找游戏模式,不存在时返回nil
+func (c *CalcScoreConf) ModeByName(name string) *ModeScoreConf {
+	for i := range c.Modes {
+		if c.Modes[i].Name == name {
+			return &c.Modes[i]
+		}
+	}
+	return nil
+}
+
+// HorseOf 游戏模式的马匹分数线,mode为nil或未配置时使用全局的
+func (c *CalcScoreConf) HorseOf(mode *ModeScoreConf) [5]HorseScoreConf {
+	if mode == nil || mode.Horse == nil {
+		return c.Horse
+	}
+	return *mode.Horse
+}
+
+// MetricEnabled 是否计算该得分项
+func (m *ModeScoreConf) MetricEnabled(metric string) bool {
+	for _, disabled := range m.DisabledMetrics {
+		if disabled == metric {
+			return false
+		}
+	}
+	return true
+}
+
+// checkScoreModels 检查自定义评分模型以及当前使用的模型
+func checkScoreModels(c *CalcScoreConf) []string {
+	problems := make([]string, 0)
+	names := map[string]bool{DefaultScorerName: true}
+	for i, model := range c.ScoreModels {
+		if model.Name == "" || names[model.Name] {
+			problems = append(problems, fmt.Sprintf("scoreModels[%d].name 为空或重复", i))
+		}
+		names[model.Name] = true
+		if len(model.Rules) == 0 {
+			problems = append(problems, fmt.Sprintf("scoreModels[%d].rules 不能为空", i))
+		}
+		for _, rule := range model.Rules {
+			if _, err := expr.ParseRule(rule, ScoreRuleVars); err != nil {
+				problems = append(problems, fmt.Sprintf("scoreModels[%d]: %v", i, err))
+			}
+		}
+	}
+	if c.Scorer != "" && !names[c.Scorer] {
+		problems = append(problems, fmt.Sprintf("scorer 评分模型 %s 不存在", c.Scorer))
+	}
+	return problems
+}
+
+// checkRoleNormalize 检查各位置的期望数据,开启时必须配置基准位置
+func checkRoleNormalize(c *RoleNormalizeConf) []string {
+	problems := make([]string, 0)
+	known := make(map[string]bool, len(ScoreRoles))
+	for _, role := range ScoreRoles {
+		known[role] = true
+	}
+	// 按固定顺序检查,保证错误信息稳定
+	for _, role := range ScoreRoles {
+		roleConf, ok := c.Roles[role]
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("roleNormalize.roles.%s", role)
+		if roleConf.MinionsPerMin <= 0 || roleConf.HurtRate <= 0 || roleConf.KillRate <= 0 {
+			problems = append(problems, fmt.Sprintf("%s 期望数据需要大于0", name))
+		}
+		weight := roleConf.Weight
+		for _, v := range []float64{weight.Minions, weight.Hurt, weight.Kill, weight.Gold, weight.Vision} {
+			if v < 0 {
+				problems = append(problems, fmt.Sprintf("%s.weight 不能为负数", name))
+				break
+			}
+		}
+	}
+	unknown := make([]string, 0)
+	for role := range c.Roles {
+		if !known[role] {
+			unknown = append(unknown, role)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		problems = append(problems, fmt.Sprintf("roleNormalize.roles 未知位置 %s", strings.Join(unknown, ",")))
+	}
+	if _, ok := c.Roles[RoleNormalizeReference]; c.Enabled && !ok {
+		problems = append(problems, fmt.Sprintf("roleNormalize.roles 缺少基准位置 %s", RoleNormalizeReference))
+	}
+	return problems
+}
+
+// checkThresholds 检查 [ [阈值,加分数] ] 列表,阈值需要从高到低
+func checkThresholds(name string, list [][2]float64) string {
+	if len(list) == 0 {
+		return fmt.Sprintf("%s 不能为空", name)
+	}
+	for i, item := range list {
+		if item[0] < 0 || item[1] < 0 {
+			return fmt.Sprintf("%s 不能为负数", name)
+		}
+		if i > 0 && item[0] >= list[i-1][0] {
+			return fmt.Sprintf("%s 需要从高到低排列", name)
+		}
+	}
+	return ""
+}
+
+// ParseScoreConf 解析评分预设json,缺少的字段使用base中的值,对象逐个字段合并,列表整体替换,不允许未知字段
+func ParseScoreConf(raw []byte, base CalcScoreConf) (*CalcScoreConf, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&CalcScoreConf{}); err != nil {
+		return nil, errors.Wrap(errBadScoreConf, err.Error())
+	}
+	baseBts, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	mergedBts, err := mergeJSON(baseBts, raw)
+	if err != nil {
+		return nil, errors.Wrap(errBadScoreConf, err.Error())
+	}
+	c := &CalcScoreConf{}
+	if err = json.Unmarshal(mergedBts, c); err != nil {
+		return nil, errors.Wrap(errBadScoreConf, err.Error())
+	}
+	if err = ValidScoreConf(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// mergeJSON 把patch合并到base,两边都是对象时递归合并,否则使用patch,避免列表中的对象与base中的同位置对象混合
+func mergeJSON(base json.RawMessage, patch json.RawMessage) (json.RawMessage, error) {
+	baseFields, patchFields := map[string]json.RawMessage{}, map[string]json.RawMessage{}
+	if !isJSONObject(base) || !isJSONObject(patch) {
+		return patch, nil
+	}
+	if err := json.Unmarshal(base, &baseFields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &patchFields); err != nil {
+		return nil, err
+	}
+	for key, val := range patchFields {
+		if baseVal, ok := baseFields[key]; ok {
+			merged, err := mergeJSON(baseVal, val)
+			if err != nil {
+				return nil, err
+			}
+			val = merged
+		}
+		baseFields[key] = val
+	}
+	return json.Marshal(baseFields)
+}
+
+func isJSONObject(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// MarshalScoreConf 导出为格式化的评分预设json
+func MarshalScoreConf(c *CalcScoreConf) ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
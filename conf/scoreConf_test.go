@@ -0,0 +1,79 @@
+package conf_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/global"
+)
+
+func TestScoreConfPreset(t *testing.T) {
+	base := global.DefaultAppConf.CalcScore
+	if err := conf.ValidScoreConf(&base); err != nil {
+		t.Fatalf("default score conf invalid: %v", err)
+	}
+	bts, err := conf.MarshalScoreConf(&base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := conf.ParseScoreConf(bts, conf.CalcScoreConf{})
+	if err != nil || !reflect.DeepEqual(*parsed, base) {
+		t.Fatalf("round trip: %+v, %v", parsed, err)
+	}
+
+	// 缺少的字段使用base,且不修改base
+	parsed, err = conf.ParseScoreConf([]byte(`{"minionsKilled":[[12,30]],"firstBlood":[20,10]}`), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.FirstBlood != [2]float64{20, 10} || len(parsed.MinionsKilled) != 1 ||
+		!reflect.DeepEqual(parsed.KillRate, base.KillRate) {
+		t.Errorf("bad merged conf %+v", parsed)
+	}
+	if !reflect.DeepEqual(base, global.DefaultAppConf.CalcScore) {
+		t.Error("base should not be modified")
+	}
+	// 对象逐个字段合并
+	parsed, err = conf.ParseScoreConf([]byte(`{"weighting":{"halfLifeHours":24},"laning":{"max":5}}`), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Weighting.HalfLifeHours != 24 || parsed.Weighting.Model != base.Weighting.Model ||
+		parsed.Weighting.HistoryDepth != base.Weighting.HistoryDepth || parsed.Laning.Max != 5 ||
+		parsed.Laning.Enabled != base.Laning.Enabled {
+		t.Errorf("bad merged nested conf %+v %+v", parsed.Weighting, parsed.Laning)
+	}
+}
+
+func TestParseScoreConfInvalid(t *testing.T) {
+	base := global.DefaultAppConf.CalcScore
+	cases := map[string]string{
+		`{"unknownWeight":1}`: "unknownWeight",
+		`{"horse":[{"score":100,"name":"a"},{"score":120,"name":"b"},{"score":90,"name":"c"},` +
+			`{"score":80,"name":"d"},{"score":1,"name":"e"}]}`: "horse.score",
+		`{"minionsKilled":[[8,5],[10,20]]}`:                               "minionsKilled",
+		`{"killRate":[{"limit":150,"scoreConf":[[15,40]]}]}`:              "killRate[0].limit",
+		`{"adjustKDA":[-1,5]}`:                                            "adjustKDA",
+		`{"scorer":"missing"}`:                                            "scorer",
+		`{"scoreModels":[{"name":"a","rules":["kills >> 1 => 5"]}]}`:      "scoreModels[0]",
+		`{"scoreModels":[{"name":"a","rules":["unknownVar > 1 => 5"]}]}`:  "unknownVar",
+		`{"scoreModels":[{"name":"default","rules":["kills > 1 => 5"]}]}`: "scoreModels[0].name",
+		`{"roleNormalize":{"roles":{"mid":{"minionsPerMin":0}}}}`:         "roles.mid",
+		`{"roleNormalize":{"roles":{"carry":{}}}}`:                        "carry",
+		`{"modes":[]}`: "modes",
+		`{"modes":[{"name":"aram","queueIDs":[450],"disabledMetrics":["wards"]}]}`: "wards",
+		`{"modes":[{"name":"aram"}]}`:                       "modes[0]",
+		`{"weighting":{"model":"linear"}}`:                  "weighting.model",
+		`{"weighting":{"model":"step","historyDepth":500}}`: "historyDepth",
+		`{"weighting":{"model":"decay","halfLifeHours":0}}`: "halfLifeHours",
+		`{"smurf":{"threshold":0}}`:                         "smurf.threshold",
+	}
+	for raw, field := range cases {
+		_, err := conf.ParseScoreConf([]byte(raw), base)
+		if err == nil || !strings.Contains(err.Error(), field) {
+			t.Errorf("%s: expect error about %s, got %v", raw, field, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package global
 import (
 	"github.com/beastars1/lol-prophet-gui/conf"
 	level "github.com/beastars1/lol-prophet-gui/pkg/logger"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
 	"log"
 	"sync"
 
@@ -47,6 +48,7 @@ var (
 var (
 	defaultShouldAutoOpenBrowserCfg = false
 	DefaultClientConf               = conf.Client{
+		Version:                        conf.ClientConfVersion,
 		AutoAcceptGame:                 false,
 		AutoPickChampID:                0,
 		AutoBanChampID:                 0,
@@ -126,16 +128,123 @@ var (
 					{5, 3},
 				}},
 			},
-			AdjustKDA: [2]float64{2, 5},
-			Horse:     horses,
-			MergeMsg:  false,
+			// 战略点/推塔相关加分默认关闭,开启后需要重新校准马匹分数线
+			ObjectiveRate:  []conf.RateItemConf{},
+			TowerRate:      []conf.RateItemConf{},
+			FirstTower:     [2]float64{0, 0},
+			FirstInhibitor: [2]float64{0, 0},
+			AdjustKDA:      [2]float64{2, 5},
+			Horse:          horses,
+			MergeMsg:       false,
+			// 按位置修正默认关闭,开启后需要重新校准马匹分数线
+			RoleNormalize: conf.RoleNormalizeConf{
+				Enabled: false,
+				Roles: map[string]conf.RoleScoreConf{
+					"top": {MinionsPerMin: 7.5, HurtRate: 22, KillRate: 20,
+						Weight: conf.RoleWeightConf{Minions: 1, Hurt: 1, Kill: 1, Gold: 1, Vision: 1}},
+					"jungle": {MinionsPerMin: 5.5, HurtRate: 17, KillRate: 22,
+						Weight: conf.RoleWeightConf{Minions: 0.5, Hurt: 1, Kill: 1, Gold: 1, Vision: 1}},
+					"mid": {MinionsPerMin: 8, HurtRate: 27, KillRate: 26,
+						Weight: conf.RoleWeightConf{Minions: 1, Hurt: 1, Kill: 1, Gold: 1, Vision: 1}},
+					"adc": {MinionsPerMin: 8.5, HurtRate: 29, KillRate: 28,
+						Weight: conf.RoleWeightConf{Minions: 1, Hurt: 1, Kill: 1, Gold: 1, Vision: 1}},
+					// 辅助不计算补兵和打钱排名,视野更重要
+					"support": {MinionsPerMin: 1.5, HurtRate: 12, KillRate: 8,
+						Weight: conf.RoleWeightConf{Minions: 0, Hurt: 1, Kill: 1, Gold: 0, Vision: 1.5}},
+				},
+			},
+			Modes: []conf.ModeScoreConf{
+				{
+					Name: "rift",
+					QueueIDs: []int{int(models.NormalQueueID), int(models.RankSoleQueueID),
+						int(models.RankFlexQueueID)},
+					MinDurationSec: 15 * 60,
+				},
+				// 大乱斗和无限火力不计算视野和补兵
+				{
+					Name:            "aram",
+					QueueIDs:        []int{int(models.ARAMQueueID)},
+					GameModes:       []string{string(models.GameModeARAM)},
+					MinDurationSec:  12 * 60,
+					DisabledMetrics: []string{conf.MetricVisionScore, conf.MetricMinionsKilled},
+				},
+				{
+					Name:            "urf",
+					QueueIDs:        []int{int(models.URFQueueID)},
+					GameModes:       []string{string(models.GameModeURF)},
+					MinDurationSec:  10 * 60,
+					DisabledMetrics: []string{conf.MetricVisionScore, conf.MetricMinionsKilled},
+				},
+			},
+			Weighting: conf.WeightingConf{
+				Model:           conf.WeightingStep,
+				StepHours:       5,
+				StepWeight:      0.8,
+				HalfLifeHours:   48,
+				RankDecay:       0.9,
+				HistoryDepth:    20,
+				ConfidenceGames: 5,
+			},
+			Laning: conf.LaningConf{
+				Enabled:     true,
+				PhaseWeight: [2]float64{0.6, 0.4},
+				CsDiff:      1,
+				XpDiff:      1,
+				GoldDiff:    1,
+				Max:         10,
+			},
+			Behavior: conf.BehaviorConf{
+				Enabled:          true,
+				MinGames:         2,
+				LeaverDamageRate: 3,
+				LeaverGoldRate:   10,
+				InterDeaths:      12,
+				InterKDA:         0.5,
+			},
+			Smurf: conf.SmurfConf{
+				Enabled:          true,
+				Threshold:        0.6,
+				MinGames:         5,
+				LowLevel:         50,
+				FewGames:         15,
+				HighWinRate:      70,
+				HighKDA:          5,
+				LowKDA:           2,
+				KDASpread:        4,
+				FewChampions:     3,
+				ManyChampionRate: 70,
+				SmurfWeights: conf.SmurfWeightConf{
+					LowLevel:     2,
+					FewGames:     1,
+					HighWinRate:  2,
+					HighKDA:      2,
+					FewChampions: 1,
+				},
+				BoostedWeights: conf.BoostedWeightConf{
+					KDASpread:     1,
+					ManyChampions: 1,
+					WinWithLowKDA: 2,
+				},
+			},
+			Streak: conf.StreakConf{
+				Enabled:         true,
+				RecentGames:     10,
+				SessionGapHours: 1,
+				TiltLosses:      5,
+				TiltHours:       2,
+			},
+			WinProbability: conf.WinProbConf{
+				Enabled:     true,
+				Coefficient: 0.1,
+				Baseline:    100,
+			},
 		},
 	}
 	userInfo   = UserInfo{}
 	confMu     = sync.Mutex{}
 	Conf       = new(conf.AppConf)
 	ClientConf = new(conf.Client)
-	Logger     *zap.SugaredLogger
+	Logger     = zap.NewNop().Sugar()
 	Cleanups   = make(map[string]func() error)
 )
 
@@ -174,7 +283,8 @@ func GetEnv() conf.Mode {
 func GetScoreConf() *conf.CalcScoreConf {
 	confMu.Lock()
 	defer confMu.Unlock()
-	return &Conf.CalcScore
+	data := Conf.CalcScore
+	return &data
 }
 
 func SetScoreConf(scoreConf conf.CalcScoreConf) {
@@ -194,35 +304,13 @@ func GetClientConf() *conf.Client {
 func SetClientConf(cfg *conf.Client) *conf.Client {
 	confMu.Lock()
 	defer confMu.Unlock()
-	if &cfg.AutoAcceptGame != nil {
-		ClientConf.AutoAcceptGame = cfg.AutoAcceptGame
-	}
-	if &cfg.AutoPickChampID != nil {
-		ClientConf.AutoPickChampID = cfg.AutoPickChampID
-	}
-	if &cfg.AutoBanChampID != nil {
-		ClientConf.AutoBanChampID = cfg.AutoBanChampID
-	}
-	if &cfg.AutoSendTeamHorse != nil {
-		ClientConf.AutoSendTeamHorse = cfg.AutoSendTeamHorse
-	}
-	if &cfg.ShouldSendSelfHorse != nil {
-		ClientConf.ShouldSendSelfHorse = cfg.ShouldSendSelfHorse
-	}
-	if &cfg.HorseNameConf != nil {
-		ClientConf.HorseNameConf = cfg.HorseNameConf
-	}
-	if &cfg.ChooseSendHorseMsg != nil {
-		ClientConf.ChooseSendHorseMsg = cfg.ChooseSendHorseMsg
-	}
-	if &cfg.ChooseChampSendMsgDelaySec != nil {
-		ClientConf.ChooseChampSendMsgDelaySec = cfg.ChooseChampSendMsgDelaySec
-	}
-	if &cfg.ShouldInGameSaveMsgToClipBoard != nil {
-		ClientConf.ShouldInGameSaveMsgToClipBoard = cfg.ShouldInGameSaveMsgToClipBoard
-	}
-	if &cfg.ShouldAutoOpenBrowser != nil {
-		ClientConf.ShouldAutoOpenBrowser = cfg.ShouldAutoOpenBrowser
+	data := *cfg
+	data.Version = conf.ClientConfVersion
+	// 界面未设置时保留原值
+	if data.ShouldAutoOpenBrowser == nil {
+		data.ShouldAutoOpenBrowser = ClientConf.ShouldAutoOpenBrowser
 	}
-	return ClientConf
+	*ClientConf = data
+	res := *ClientConf
+	return &res
 }
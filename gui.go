@@ -10,6 +10,7 @@ import (
 	"github.com/beastars1/lol-prophet-gui/champion"
 	"github.com/beastars1/lol-prophet-gui/conf"
 	"github.com/beastars1/lol-prophet-gui/global"
+	"strings"
 	"sync"
 	"time"
 )
@@ -31,6 +32,14 @@ type gui struct {
 }
 
 func (g *gui) RunProphet() {
+	g.p.OnConnStateChange(func(evt ConnStateEvent) {
+		switch {
+		case evt.To == ConnStateSubscribed:
+			Append("已连接lol客户端")
+		case evt.To == ConnStateLost && evt.From == ConnStateSubscribed:
+			Append("与lol客户端的连接已断开，正在重新连接")
+		}
+	})
 	g.p.Run()
 }
 
@@ -91,7 +100,9 @@ func (g *gui) LoadUI(app fyne.App) {
 				g.queryHorse(player.Text)
 			})),
 		container.NewGridWithColumns(3,
-			container.NewGridWithColumns(1),
+			widget.NewButton("详情", func() {
+				g.showPlayerDetail(app, player.Text)
+			}),
 			container.NewGridWithColumns(1),
 			widget.NewButton("清屏", func() {
 				display("")
@@ -104,7 +115,9 @@ func (g *gui) LoadUI(app fyne.App) {
 			g.queryHorse("")
 		}),
 		container.NewGridWithColumns(1),
-		container.NewGridWithColumns(1),
+		widget.NewButton("评分设置", func() {
+			g.showScoreSettings(app)
+		}),
 		container.NewGridWithColumns(1),
 		widget.NewButton("保存", func() {
 			g.update()
@@ -131,12 +144,21 @@ func newBindEntry(data binding.String) *widget.Entry {
 }
 
 func (g *gui) queryHorse(player string) {
-	name, score, kda, horse, err := g.p.queryBySummonerName(player)
+	scoreInfo, horse, err := g.p.queryBySummonerName(player)
 	if err != nil {
 		Append(err)
 		return
 	}
-	Append(fmt.Sprintf("%s：%s 得分：%.1f 近期KDA：%s", name, horse, score, kda))
+	Append(fmt.Sprintf("%s：%s%s 得分：%.1f%s%s%s 可信度：%.0f%% 近期KDA：%s", scoreInfo.SummonerName, horse,
+		accountTag(scoreInfo.Account), scoreInfo.Score, modeFallbackTag(scoreInfo.ModeFallback),
+		laningString(scoreInfo.Laning), badgesString(scoreInfo.Badges),
+		scoreInfo.Confidence*100, kdaString(scoreInfo.CurrKDA, 5)))
+	if msg := streakDetail(scoreInfo.Streak); msg != "" {
+		Append(fmt.Sprintf("近期状态：%s", msg))
+	}
+	if len(scoreInfo.ModelScores) > 0 {
+		Append(fmt.Sprintf("模型对比：%s", modelScoresString(scoreInfo.ModelScores, scoreInfo.Mode)))
+	}
 }
 
 func (g *gui) update() {
@@ -154,8 +176,7 @@ func display(newtext string) {
 
 func Append(newtext ...interface{}) {
 	original := GetLol().output.Text
-	text := fmt.Sprint(newtext)
-	text = text[1 : len(text)-1]
+	text := strings.TrimSuffix(fmt.Sprintln(newtext...), "\n")
 	GetLol().output.SetText(original + fmt.Sprintf("%s : %s\n", time.Now().Format(layout), text))
 }
 
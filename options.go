@@ -19,3 +19,10 @@ func WithProd() ApplyOption {
 		o.debug = false
 	}
 }
+
+// WithOutput 设置消息输出,默认输出到gui窗口
+func WithOutput(output func(text ...interface{})) ApplyOption {
+	return func(o *options) {
+		o.output = output
+	}
+}
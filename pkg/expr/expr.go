@@ -0,0 +1,359 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// 简单的数值表达式,支持 + - * / 比较 && || ! 括号 以及 min max abs 函数
+// 比较和逻辑运算的结果为 1/0,除数为0时结果为0
+
+type (
+	// Env 变量取值
+	Env map[string]float64
+	// Node 表达式节点
+	Node interface {
+		Eval(env Env) float64
+	}
+	// Rule 评分规则 条件 => 加分
+	Rule struct {
+		Src   string
+		Cond  Node
+		Value Node
+	}
+
+	numNode   float64
+	varNode   string
+	unaryNode struct {
+		op string
+		x  Node
+	}
+	binaryNode struct {
+		op   string
+		l, r Node
+	}
+	callNode struct {
+		fn   string
+		args []Node
+	}
+
+	token struct {
+		kind string // num ident op eof
+		text string
+		pos  int
+	}
+	parser struct {
+		src    string
+		tokens []token
+		pos    int
+		vars   map[string]bool
+	}
+)
+
+var funcArgs = map[string]int{
+	"min": 2,
+	"max": 2,
+	"abs": 1,
+}
+
+// Parse 解析表达式,vars 为允许使用的变量,为nil时不检查
+func Parse(src string, vars []string) (Node, error) {
+	p, err := newParser(src, vars)
+	if err != nil {
+		return nil, err
+	}
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != "eof" {
+		return nil, p.errorf(tok, "多余的 %q", tok.text)
+	}
+	return node, nil
+}
+
+// ParseRule 解析 "条件 => 加分" 形式的规则,例如 kills/teamKills > 0.5 && kills > 10 => +20
+func ParseRule(src string, vars []string) (*Rule, error) {
+	idx := strings.Index(src, "=>")
+	if idx < 0 {
+		return nil, errors.Errorf("规则缺少 => : %s", src)
+	}
+	cond, err := Parse(src[:idx], vars)
+	if err != nil {
+		return nil, errors.Wrapf(err, "规则条件错误 %s", src)
+	}
+	value, err := Parse(src[idx+2:], vars)
+	if err != nil {
+		return nil, errors.Wrapf(err, "规则加分错误 %s", src)
+	}
+	return &Rule{Src: strings.TrimSpace(src), Cond: cond, Value: value}, nil
+}
+
+// Apply 条件成立时返回加分
+func (r *Rule) Apply(env Env) (float64, bool) {
+	if r.Cond.Eval(env) == 0 {
+		return 0, false
+	}
+	return r.Value.Eval(env), true
+}
+
+func newParser(src string, vars []string) (*parser, error) {
+	p := &parser{src: src}
+	if vars != nil {
+		p.vars = make(map[string]bool, len(vars))
+		for _, v := range vars {
+			p.vars[v] = true
+		}
+	}
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: "num", text: src[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: "ident", text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", ">=", "<=", "==", "!=", ">", "<", "+", "-", "*", "/",
+				"!", "(", ")", ","} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, errors.Errorf("位置%d: 无法识别的字符 %q", i, c)
+			}
+			p.tokens = append(p.tokens, token{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: "eof", pos: len(src)})
+	return p, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != "eof" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != "op" {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return errors.Errorf("位置%d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseExpr() (Node, error) {
+	return p.parseBinary(0)
+}
+
+// 优先级从低到高
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", ">", ">=", "<", "<="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *parser) parseBinary(level int) (Node, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	l, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(binaryLevels[level]...)
+		if !ok {
+			return l, nil
+		}
+		r, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if op, ok := p.accept("!", "-", "+"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case "num":
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "错误的数字 %s", tok.text)
+		}
+		return numNode(v), nil
+	case "ident":
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(tok)
+		}
+		if p.vars != nil && !p.vars[tok.text] {
+			return nil, p.errorf(tok, "未知变量 %s", tok.text)
+		}
+		return varNode(tok.text), nil
+	case "op":
+		if tok.text == "(" {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, p.errorf(p.peek(), "缺少 )")
+			}
+			return x, nil
+		}
+	case "eof":
+		return nil, p.errorf(tok, "表达式不完整")
+	}
+	return nil, p.errorf(tok, "意外的 %q", tok.text)
+}
+
+func (p *parser) parseCall(fnTok token) (Node, error) {
+	argc, ok := funcArgs[fnTok.text]
+	if !ok {
+		return nil, p.errorf(fnTok, "未知函数 %s", fnTok.text)
+	}
+	args := make([]Node, 0, argc)
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if _, ok := p.accept(","); ok {
+			continue
+		}
+		if _, ok := p.accept(")"); ok {
+			break
+		}
+		return nil, p.errorf(p.peek(), "缺少 )")
+	}
+	if len(args) != argc {
+		return nil, p.errorf(fnTok, "%s 需要%d个参数", fnTok.text, argc)
+	}
+	return callNode{fn: fnTok.text, args: args}, nil
+}
+
+func (n numNode) Eval(Env) float64 {
+	return float64(n)
+}
+
+func (n varNode) Eval(env Env) float64 {
+	return env[string(n)]
+}
+
+func (n unaryNode) Eval(env Env) float64 {
+	x := n.x.Eval(env)
+	switch n.op {
+	case "!":
+		return boolVal(x == 0)
+	case "-":
+		return -x
+	}
+	return x
+}
+
+func (n binaryNode) Eval(env Env) float64 {
+	l := n.l.Eval(env)
+	// 短路求值
+	switch n.op {
+	case "&&":
+		return boolVal(l != 0 && n.r.Eval(env) != 0)
+	case "||":
+		return boolVal(l != 0 || n.r.Eval(env) != 0)
+	}
+	r := n.r.Eval(env)
+	switch n.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	case "==":
+		return boolVal(l == r)
+	case "!=":
+		return boolVal(l != r)
+	case ">":
+		return boolVal(l > r)
+	case ">=":
+		return boolVal(l >= r)
+	case "<":
+		return boolVal(l < r)
+	case "<=":
+		return boolVal(l <= r)
+	}
+	return 0
+}
+
+func (n callNode) Eval(env Env) float64 {
+	switch n.fn {
+	case "min":
+		return math.Min(n.args[0].Eval(env), n.args[1].Eval(env))
+	case "max":
+		return math.Max(n.args[0].Eval(env), n.args[1].Eval(env))
+	case "abs":
+		return math.Abs(n.args[0].Eval(env))
+	}
+	return 0
+}
+
+func boolVal(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	vars := []string{"kills", "deaths", "teamKills", "win"}
+	rule, err := ParseRule("kills/teamKills > 0.5 && kills > 10 => +20", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		env   Env
+		value float64
+		ok    bool
+	}{
+		{Env{"kills": 12, "teamKills": 20}, 20, true},
+		{Env{"kills": 12, "teamKills": 30}, 0, false},
+		{Env{"kills": 8, "teamKills": 10}, 0, false},
+		{Env{"kills": 12}, 0, false}, // 除数为0
+	}
+	for _, c := range cases {
+		value, ok := rule.Apply(c.env)
+		if value != c.value || ok != c.ok {
+			t.Errorf("%v: got %v %v", c.env, value, ok)
+		}
+	}
+
+	rule, err = ParseRule("!win || deaths >= max(kills, 5) => -(deaths - kills) * 2", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := rule.Apply(Env{"win": 1, "deaths": 9, "kills": 3}); !ok || value != -12 {
+		t.Errorf("got %v %v", value, ok)
+	}
+	if _, ok := rule.Apply(Env{"win": 1, "deaths": 4, "kills": 3}); ok {
+		t.Error("rule should not apply")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	vars := []string{"kills"}
+	cases := map[string]string{
+		"kills > 10":             "=>",
+		"golds > 10 => 5":        "未知变量 golds",
+		"kills > => 5":           "表达式不完整",
+		"(kills > 10 => 5":       "缺少 )",
+		"kills # 10 => 5":        "无法识别",
+		"pow(kills, 2) > 1 => 1": "未知函数 pow",
+		"min(kills) > 1 => 1":    "需要2个参数",
+		"kills > 1 => 5 5":       "多余",
+	}
+	for src, msg := range cases {
+		_, err := ParseRule(src, vars)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: expect %s, got %v", src, msg, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package lol_prophet_gui
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/beastars1/lol-prophet-gui/champion"
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/global"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+	"strings"
+)
+
+var queueNames = map[models.GameQueueID]string{
+	models.NormalQueueID:   "匹配",
+	models.RankSoleQueueID: "单排",
+	models.RankFlexQueueID: "组排",
+	models.ARAMQueueID:     "大乱斗",
+}
+
+var positionNames = map[models.Position]string{
+	models.PositionTop:     "上单",
+	models.PositionJungle:  "打野",
+	models.PositionMid:     "中单",
+	models.PositionADC:     "adc",
+	models.PositionSupport: "辅助",
+}
+
+// showPlayerDetail 玩家详情页面,列出参与计算的每一局得分及原因
+func (g *gui) showPlayerDetail(app fyne.App, player string) {
+	scoreInfo, horse, err := g.p.queryBySummonerName(player)
+	if err != nil {
+		Append(err)
+		return
+	}
+	w := app.NewWindow(fmt.Sprintf("玩家详情 %s", scoreInfo.SummonerName))
+	detail := widget.NewMultiLineEntry()
+	detail.Wrapping = fyne.TextWrapWord
+	detail.SetText(playerDetailText(scoreInfo, horse))
+	w.SetContent(container.NewBorder(
+		widget.NewLabel(fmt.Sprintf("%s：%s 得分：%.1f", scoreInfo.SummonerName, horse, scoreInfo.Score)),
+		nil, nil, nil,
+		container.NewScroll(detail)))
+	w.Resize(resize(800, 600))
+	w.Show()
+}
+
+// playerDetailText 马匹档位说明以及每一局的得分明细
+func playerDetailText(scoreInfo *lcu.UserScore, horse string) string {
+	sb := strings.Builder{}
+	sb.WriteString(horseExplain(scoreInfo.Score, horse, scoreInfo.Mode))
+	sb.WriteString("\n")
+	if scoreInfo.ModeFallback {
+		sb.WriteString("当前模式没有对局,使用全部模式的对局计算,按全局马匹分数线判断\n")
+	}
+	if len(scoreInfo.Games) == 0 {
+		sb.WriteString("没有可计算的对局,使用默认分数\n")
+		return sb.String()
+	}
+	if msg := streakDetail(scoreInfo.Streak); msg != "" {
+		sb.WriteString(fmt.Sprintf("近期状态：%s\n", msg))
+	}
+	if account := scoreInfo.Account; account != nil {
+		sb.WriteString(fmt.Sprintf("疑似小号%.0f%%%s 疑似代练/共享账号%.0f%%%s\n", account.Smurf*100,
+			reasonsString(account.SmurfReasons), account.Boosted*100, reasonsString(account.BoostedReasons)))
+	}
+	if len(scoreInfo.Badges) > 0 {
+		sb.WriteString(fmt.Sprintf("近期行为标记%s,不影响得分\n", badgesString(scoreInfo.Badges)))
+	}
+	if scoreInfo.Laning != nil {
+		sb.WriteString(fmt.Sprintf("对线得分%+.1f,根据前20分钟与对位玩家的补刀/经验/金钱差计算,0为均势\n", *scoreInfo.Laning))
+	}
+	sb.WriteString(fmt.Sprintf("共%d局,有效对局数%.1f,可信度%.0f%%,%s\n\n", len(scoreInfo.Games),
+		scoreInfo.EffectiveGames, scoreInfo.Confidence*100, weightingExplain(global.GetScoreConf().Weighting)))
+	for _, game := range scoreInfo.Games {
+		result := "负"
+		if game.Win {
+			result = "胜"
+		}
+		queue, ok := queueNames[game.QueueID]
+		if !ok {
+			queue = fmt.Sprintf("队列%d", game.QueueID)
+		}
+		if position, ok := positionNames[game.Position]; ok {
+			queue += " " + position
+		}
+		sb.WriteString(fmt.Sprintf("%s %s %s %s %d/%d/%d 得分%.1f%s 权重%.0f%%\n", game.GameCreation.Local().Format("01-02 15:04"),
+			queue, champion.GetNameByKey(game.ChampionID), result, game.KDA[0], game.KDA[1], game.KDA[2], game.Score,
+			laningString(game.Laning), game.Weight*100))
+		if len(game.Behaviors) > 0 {
+			behaviors := make([]string, 0, len(game.Behaviors))
+			for _, behavior := range game.Behaviors {
+				behaviors = append(behaviors, string(behavior))
+			}
+			sb.WriteString(fmt.Sprintf("    疑似%s\n", strings.Join(behaviors, "、")))
+		}
+		for _, reason := range game.Reasons {
+			sb.WriteString(fmt.Sprintf("    %s %+.2f\n", reason.Reason, reason.IncVal))
+		}
+	}
+	return sb.String()
+}
+
+func reasonsString(reasons []string) string {
+	if len(reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%s)", strings.Join(reasons, ","))
+}
+
+// weightingExplain 加权方式说明
+func weightingExplain(c conf.WeightingConf) string {
+	switch c.Model {
+	case conf.WeightingDecay:
+		return fmt.Sprintf("对局权重每%.0f小时减半", c.HalfLifeHours)
+	case conf.WeightingRank:
+		return fmt.Sprintf("每往前一局权重乘以%.2f", c.RankDecay)
+	}
+	return fmt.Sprintf("近%.0f小时内的对局权重%.1f,其余%.1f", c.StepHours, c.StepWeight, 1-c.StepWeight)
+}
+
+// horseExplain 说明分数落在哪个档位以及距离上一档还差多少
+func horseExplain(score float64, horse string, modeName string) string {
+	scoreCfg := global.GetScoreConf()
+	horses := scoreCfg.HorseOf(scoreCfg.ModeByName(modeName))
+	for i, v := range horses {
+		if score < v.Score {
+			continue
+		}
+		text := fmt.Sprintf("得分%.1f ≥ %s分数线%.1f", score, horse, v.Score)
+		if i > 0 {
+			text += fmt.Sprintf(",距离%s(%.1f)还差%.1f", global.GetClientConf().HorseNameConf[i-1],
+				horses[i-1].Score, horses[i-1].Score-score)
+		}
+		return text
+	}
+	return fmt.Sprintf("得分%.1f 低于所有分数线", score)
+}
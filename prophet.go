@@ -12,8 +12,11 @@ import (
 	"github.com/beastars1/lol-prophet-gui/services/lcu"
 	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
 	"github.com/beastars1/lol-prophet-gui/services/logger"
+	"github.com/beastars1/lol-prophet-gui/services/scorer"
+	"github.com/beastars1/lol-prophet-gui/services/ws"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -31,30 +34,40 @@ type (
 	lcuWsEvt  string
 	GameState string
 	Prophet   struct {
-		ctx          context.Context
-		opts         *options
-		httpSrv      *http.Server
-		lcuPort      int
-		lcuToken     string
-		lcuActive    bool
-		currSummoner *lcu.CurrSummoner
-		cancel       func()
-		mu           *sync.Mutex
-		GameState    GameState
-	}
-	wsMsg struct {
-		Data      interface{} `json:"data"`
-		EventType string      `json:"event_type"`
-		Uri       string      `json:"uri"`
+		ctx           context.Context
+		opts          *options
+		httpSrv       *http.Server
+		lcuPort       int
+		lcuToken      string
+		lcuActive     bool
+		discoverers   lcu.Discoverers
+		connState     ConnState
+		connListeners []ConnStateListener
+		events        *lcu.EventBus
+		currSummoner  *lcu.CurrSummoner
+		cancel        func()
+		mu            *sync.Mutex
+		champSelect   *champSelectState
+		GameState     GameState
+	}
+	// champSelectState 选人阶段已算出的队友得分,以及每个队友上次提示过的英雄
+	champSelectState struct {
+		mu     sync.Mutex
+		scores map[string]lcu.UserScore
+		shown  map[string]championPick
+	}
+	championPick struct {
+		championID int
+		locked     bool
 	}
 	options struct {
 		debug       bool
 		enablePprof bool
+		output      func(text ...interface{})
 	}
 )
 
 const (
-	onJsonApiEventPrefixLen              = len(`[8,"OnJsonApiEvent",`)
 	gameFlowChangedEvt          lcuWsEvt = "/lol-gameflow/v1/gameflow-phase"
 	champSelectUpdateSessionEvt lcuWsEvt = "/lol-champ-select/v1/session"
 )
@@ -69,7 +82,7 @@ const (
 )
 
 var (
-	defaultOpts = &options{
+	defaultOpts = options{
 		debug:       false,
 		enablePprof: true,
 	}
@@ -77,12 +90,17 @@ var (
 
 func NewProphet(opts ...ApplyOption) *Prophet {
 	ctx, cancel := context.WithCancel(context.Background())
+	pOpts := defaultOpts
 	p := &Prophet{
-		ctx:       ctx,
-		cancel:    cancel,
-		mu:        &sync.Mutex{},
-		opts:      defaultOpts,
-		GameState: GameStateNone,
+		ctx:         ctx,
+		cancel:      cancel,
+		mu:          &sync.Mutex{},
+		champSelect: &champSelectState{},
+		opts:        &pOpts,
+		discoverers: lcu.NewDiscoverers(&global.Conf.Lcu),
+		events:      lcu.NewEventBus(),
+		connState:   ConnStateLost,
+		GameState:   GameStateNone,
 	}
 	if global.IsDevMode() {
 		opts = append(opts, WithDebug())
@@ -92,25 +110,47 @@ func NewProphet(opts ...ApplyOption) *Prophet {
 	for _, fn := range opts {
 		fn(p.opts)
 	}
+	if p.opts.output == nil {
+		p.opts.output = Append
+	}
+	p.OnConnStateChange(func(evt ConnStateEvent) {
+		logger.Debug("lcu连接状态变化", zap.String("from", string(evt.From)), zap.String("to", string(evt.To)),
+			zap.Int("port", evt.Port), zap.Error(evt.Err))
+	})
+	p.registerLcuEventHandlers()
 	return p
 }
 
 func (p *Prophet) Run() {
 	go p.MonitorStart()
 	go p.captureStartMessage()
-	Append(fmt.Sprintf("%s已启动，当前版本: %s", global.AppName, global.Version))
-	Append(fmt.Sprintf("项目地址: %s", global.ProjectUrl))
+	p.opts.output(fmt.Sprintf("%s已启动，当前版本: %s", global.AppName, global.Version))
+	p.opts.output(fmt.Sprintf("项目地址: %s", global.ProjectUrl))
 	go func() {
 		if ok, downloadUrl, info := CheckUpdate(); ok {
-			Append(fmt.Sprintf("发现新版本，下载地址： %s\n更新日志：\n%s", downloadUrl, info))
+			p.opts.output(fmt.Sprintf("发现新版本，下载地址： %s\n更新日志：\n%s", downloadUrl, info))
 		}
 	}()
 }
 
 func (p *Prophet) isLcuActive() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	return p.lcuActive
 }
 
+func (p *Prophet) getCurrSummoner() *lcu.CurrSummoner {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.currSummoner
+}
+
+func (p *Prophet) setCurrSummoner(summoner *lcu.CurrSummoner) {
+	p.mu.Lock()
+	p.currSummoner = summoner
+	p.mu.Unlock()
+}
+
 func (p *Prophet) Stop() error {
 	if p.cancel != nil {
 		p.cancel()
@@ -119,35 +159,72 @@ func (p *Prophet) Stop() error {
 	return nil
 }
 
+// MonitorStart 连接监控: 查找客户端 -> 连接 -> 获取召唤师 -> 订阅事件, 断开后退避重试, 直到 Stop
 func (p *Prophet) MonitorStart() {
-	for {
-		if !p.isLcuActive() {
-			port, token, err := lcu.GetLolClientApiInfo()
-			if err != nil {
-				if !errors.Is(lcu.ErrLolProcessNotFound, err) {
-					logger.Error("获取lcu info 失败", zap.Error(err))
-				}
-				time.Sleep(time.Second)
-				continue
+	bo := newBackoff(minReconnectDelay, maxReconnectDelay)
+	for p.ctx.Err() == nil {
+		p.setConnState(ConnStateSearching, 0, nil)
+		port, token, name, err := p.discoverers.DiscoverWithName()
+		if err != nil {
+			if !errors.Is(err, lcu.ErrLolProcessNotFound) {
+				logger.Error("获取lcu info 失败", zap.Error(err))
 			}
-			p.initLcuClient(port, token)
-			err = p.initGameFlowMonitor(port, token)
-			if err != nil {
-				logger.Debug("游戏流程监视器 err:", zap.Error(err))
+			if !p.sleep(bo.Next()) {
+				break
 			}
-			p.lcuActive = false
-			p.currSummoner = nil
+			continue
+		}
+		logger.Debug("发现lol客户端", zap.String("discoverer", name), zap.Int("port", port))
+		p.setConnState(ConnStateConnecting, port, nil)
+		p.initLcuClient(port, token)
+		subscribed, err := p.initGameFlowMonitor(port, token)
+		if err != nil {
+			logger.Debug("游戏流程监视器 err:", zap.Error(err))
+		}
+		p.setCurrSummoner(nil)
+		p.setConnState(ConnStateLost, port, err)
+		if subscribed {
+			bo.Reset()
+		}
+		if !p.sleep(bo.Next()) {
+			break
 		}
-		time.Sleep(time.Second)
 	}
+	p.setConnState(ConnStateLost, 0, p.ctx.Err())
 }
 
 func (p *Prophet) initLcuClient(port int, token string) {
 	lcu.InitCli(port, token)
 }
 
-func (p *Prophet) initGameFlowMonitor(port int, authPwd string) error {
-	dialer := websocket.DefaultDialer
+// watchLcuConn 在 Prophet 停止或客户端端口/token变化时关闭连接,使读取循环退出
+func (p *Prophet) watchLcuConn(c *websocket.Conn, port int, token string, done <-chan struct{}) {
+	ticker := time.NewTicker(rediscoveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-p.ctx.Done():
+			_ = c.Close()
+			return
+		case <-ticker.C:
+			currPort, currToken, err := p.discoverers.Discover()
+			if err != nil {
+				continue
+			}
+			if currPort != port || currToken != token {
+				logger.Info("lol客户端端口或token已变化,重新连接", zap.Int("port", currPort))
+				_ = c.Close()
+				return
+			}
+		}
+	}
+}
+
+// initGameFlowMonitor 连接客户端websocket并处理事件,返回是否成功订阅过事件
+func (p *Prophet) initGameFlowMonitor(port int, authPwd string) (bool, error) {
+	dialer := *websocket.DefaultDialer
 	dialer.TLSClientConfig = &tls.Config{
 		InsecureSkipVerify: true,
 	}
@@ -157,73 +234,87 @@ func (p *Prophet) initGameFlowMonitor(port int, authPwd string) error {
 	header.Set("Authorization", "Basic "+authSecret)
 	u, _ := url.Parse(rawUrl)
 	logger.Debug(fmt.Sprintf("connect to lcu %s", u.String()))
-	c, _, err := dialer.Dial(u.String(), header)
+	c, _, err := dialer.DialContext(p.ctx, u.String(), header)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer c.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go p.watchLcuConn(c, port, authPwd, done)
 	err = retry.Do(func() error {
-		currSummoner, err := lcu.GetCurrSummoner()
+		currSummoner, err := lcu.GetCurrSummoner(p.ctx)
 		if err == nil {
-			p.currSummoner = currSummoner
+			p.setCurrSummoner(currSummoner)
 		}
 		return err
-	}, retry.Attempts(5), retry.Delay(time.Second))
+	}, retry.Attempts(5), retry.Delay(time.Second), retry.Context(p.ctx))
 	if err != nil {
-		return errors.New("获取当前召唤师信息失败:" + err.Error())
+		return false, errors.New("获取当前召唤师信息失败:" + err.Error())
 	}
-	p.lcuActive = true
+	p.setConnState(ConnStateAuthenticated, port, nil)
 
-	_ = c.WriteMessage(websocket.TextMessage, []byte("[5, \"OnJsonApiEvent\"]"))
+	err = c.WriteMessage(websocket.TextMessage, lcu.EncodeWampMessage(lcu.WampOpcodeSubscribe,
+		lcu.OnJsonApiEventTopic))
+	if err != nil {
+		return false, err
+	}
+	p.setConnState(ConnStateSubscribed, port, nil)
 	for {
 		msgType, message, err := c.ReadMessage()
 		if err != nil {
 			logger.Debug("lol事件监控读取消息失败", zap.Error(err))
-			return err
+			return true, err
 		}
-		msg := &wsMsg{}
-		if msgType != websocket.TextMessage || len(message) < onJsonApiEventPrefixLen+1 {
+		if msgType != websocket.TextMessage || len(message) == 0 {
 			continue
 		}
-		_ = json.Unmarshal(message[onJsonApiEventPrefixLen:len(message)-1], msg)
-		//Append("lol事件监控读取消息 -> ", msg.Uri)
-		switch msg.Uri {
-		case string(gameFlowChangedEvt):
-			gameFlow, ok := msg.Data.(string)
-			if !ok {
-				continue
-			}
-			p.onGameFlowUpdate(gameFlow)
-		case string(champSelectUpdateSessionEvt):
-			bts, err := json.Marshal(msg.Data)
-			if err != nil {
-				continue
-			}
-			sessionInfo := &lcu.ChampSelectSessionInfo{}
-			err = json.Unmarshal(bts, sessionInfo)
-			if err != nil {
-				logger.Warn("解析结构体失败", err)
-				continue
-			}
-			go func() {
-				_ = p.onChampSelectSessionUpdate(sessionInfo)
-			}()
-		default:
-
+		evt, err := lcu.DecodeEvent(message)
+		if err != nil {
+			continue
 		}
+		p.events.Publish(evt)
 	}
 }
 
+// Events lcu事件总线,新功能通过订阅uri接入,无需修改读取循环
+func (p *Prophet) Events() *lcu.EventBus {
+	return p.events
+}
+
+func (p *Prophet) registerLcuEventHandlers() {
+	p.events.Subscribe(string(gameFlowChangedEvt), func(evt *lcu.Event) {
+		var gameFlow string
+		if err := evt.Decode(&gameFlow); err != nil {
+			return
+		}
+		p.onGameFlowUpdate(gameFlow)
+	}, lcu.EventTypeCreate, lcu.EventTypeUpdate)
+	p.events.Subscribe(string(champSelectUpdateSessionEvt), func(evt *lcu.Event) {
+		sessionInfo := &lcu.ChampSelectSessionInfo{}
+		if err := evt.Decode(sessionInfo); err != nil {
+			logger.Warn("解析结构体失败", err)
+			return
+		}
+		go func() {
+			_ = p.onChampSelectSessionUpdate(sessionInfo)
+		}()
+	}, lcu.EventTypeCreate, lcu.EventTypeUpdate)
+}
+
 func (p *Prophet) onGameFlowUpdate(gameFlow string) {
 	//Append("切换状态:" + gameFlow)
 	switch gameFlow {
 	case string(models.GameFlowChampionSelect):
-		Append("进入英雄选择阶段，正在计算分数")
+		p.opts.output("进入英雄选择阶段，正在计算分数")
 		sentry.WithScope(func(scope *sentry.Scope) {
-			scope.SetTag("player", p.currSummoner.DisplayName)
+			if currSummoner := p.getCurrSummoner(); currSummoner != nil {
+				scope.SetTag("player", currSummoner.DisplayName)
+			}
 			sentry.CaptureMessage("进入英雄选择阶段，正在计算分数")
 		})
 		p.updateGameState(GameStateChampSelect)
+		p.champSelect.reset(nil)
 		go p.ChampionSelectStart()
 	case string(models.GameFlowNone):
 		p.updateGameState(GameStateNone)
@@ -270,31 +361,33 @@ func (p Prophet) ChampionSelectStart() {
 		time.Second*time.Duration(clientCfg.ChooseChampSendMsgDelaySec))
 	defer cancel()
 	var conversationID string
-	var summonerIDList []int64
+	var puuidList []string
+	// 队友陆续进入聊天组,人齐或重试3次后开始计算
 	for i := 0; i < 3; i++ {
 		time.Sleep(time.Second)
 		// 获取队伍所有玩家信息
-		conversationID, summonerIDList, _ = getTeamUsers()
-		if len(summonerIDList) != 5 {
-			continue
+		conversationID, puuidList, _ = getTeamUsers(p.ctx)
+		if len(puuidList) == 5 {
+			break
 		}
 	}
 
-	logger.Debug("队伍人员列表:", zap.Any("summonerIDList", summonerIDList))
+	logger.Debug("队伍人员列表:", zap.Any("puuidList", puuidList))
 	// 查询所有用户的信息并计算得分
+	modeName := p.currentScoreMode()
 	g := errgroup.Group{}
-	summonerIDMapScore := map[int64]lcu.UserScore{}
+	puuidMapScore := map[string]lcu.UserScore{}
 	mu := sync.Mutex{}
-	for _, summonerID := range summonerIDList {
-		summonerID := summonerID
+	for _, puuid := range puuidList {
+		puuid := puuid
 		g.Go(func() error {
-			actScore, err := GetUserScore(summonerID)
+			actScore, err := GetUserScore(p.ctx, puuid, modeName)
 			if err != nil {
-				logger.Error("计算玩家分数失败", zap.Error(err), zap.Int64("summonerID", summonerID))
+				logger.Error("计算玩家分数失败", zap.Error(err), zap.String("puuid", puuid))
 				return nil
 			}
 			mu.Lock()
-			summonerIDMapScore[summonerID] = *actScore
+			puuidMapScore[puuid] = *actScore
 			mu.Unlock()
 			return nil
 		})
@@ -302,13 +395,14 @@ func (p Prophet) ChampionSelectStart() {
 	_ = g.Wait()
 
 	scoreCfg := global.GetScoreConf()
+	currSummoner := p.getCurrSummoner()
 	allMsg := ""
 	mergedMsg := ""
 	// 发送到选人界面
-	for _, scoreInfo := range summonerIDMapScore {
+	for _, scoreInfo := range puuidMapScore {
 		var horse string
 		horseIdx := 0
-		for i, v := range scoreCfg.Horse {
+		for i, v := range scoreCfg.HorseOf(scoreCfg.ModeByName(scoreInfo.Mode)) {
 			if scoreInfo.Score >= v.Score {
 				horse = clientCfg.HorseNameConf[i]
 				horseIdx = i
@@ -325,24 +419,26 @@ func (p Prophet) ChampionSelectStart() {
 			currKDAMsg = currKDAMsg[:len(currKDAMsg)-1]
 		}
 
-		msg := fmt.Sprintf("本局%s：%s 得分：%.1f  近期KDA：%s", horse, scoreInfo.SummonerName, scoreInfo.Score, currKDAMsg)
+		msg := fmt.Sprintf("本局%s%s：%s 得分：%.1f%s%s%s  近期KDA：%s", horse, accountTag(scoreInfo.Account),
+			scoreInfo.SummonerName, scoreInfo.Score, modeFallbackTag(scoreInfo.ModeFallback),
+			laningString(scoreInfo.Laning), badgesString(scoreInfo.Badges)+streakString(scoreInfo.Streak), currKDAMsg)
 		//log.Printf(msg)
-		Append(msg)
+		p.opts.output(msg)
 		<-sendConversationMsgDelayCtx.Done()
 		if clientCfg.AutoSendTeamHorse {
 			mergedMsg += msg + "\n"
 		}
 		if !clientCfg.AutoSendTeamHorse {
-			if !scoreCfg.MergeMsg && !clientCfg.ShouldSendSelfHorse && p.currSummoner != nil &&
-				scoreInfo.SummonerID == p.currSummoner.SummonerId {
+			if !scoreCfg.MergeMsg && !clientCfg.ShouldSendSelfHorse && currSummoner != nil &&
+				scoreInfo.Puuid == currSummoner.Puuid {
 				continue
 			}
 			allMsg += msg + "\n"
 			mergedMsg += msg + "\n"
 			continue
 		}
-		if !clientCfg.ShouldSendSelfHorse && p.currSummoner != nil &&
-			scoreInfo.SummonerID == p.currSummoner.SummonerId {
+		if !clientCfg.ShouldSendSelfHorse && currSummoner != nil &&
+			scoreInfo.Puuid == currSummoner.Puuid {
 			continue
 		}
 		if !clientCfg.ChooseSendHorseMsg[horseIdx] {
@@ -351,65 +447,91 @@ func (p Prophet) ChampionSelectStart() {
 		if scoreCfg.MergeMsg {
 			continue
 		}
-		_ = SendConversationMsg(msg, conversationID)
+		if err := SendConversationMsg(p.ctx, msg, conversationID); err != nil {
+			logger.Info("发送马匹信息失败", zap.Error(err))
+		}
 		time.Sleep(time.Millisecond * 1500)
 	}
+	p.champSelect.reset(puuidMapScore)
+	// 得分算出之前已经预选的英雄
+	if session, err := lcu.GetChampSelectSession(p.ctx); err == nil {
+		p.outputChampionPicks(session)
+	}
+	if msg, ok := p.outputWinEstimate(teamScores(puuidMapScore, puuidList), nil); ok {
+		allMsg += msg + "\n"
+		mergedMsg += msg + "\n"
+	}
 	if !clientCfg.AutoSendTeamHorse {
-		Append("已将队伍马匹信息复制到剪切板")
+		p.opts.output("已将队伍马匹信息复制到剪切板")
 		_ = clipboard.WriteAll(allMsg)
 		return
 	}
 	if scoreCfg.MergeMsg {
-		_ = SendConversationMsg(mergedMsg, conversationID)
+		if err := SendConversationMsg(p.ctx, mergedMsg, conversationID); err != nil {
+			logger.Info("发送马匹信息失败", zap.Error(err))
+		}
 	}
 }
 
 func (p Prophet) AcceptGame() {
-	_ = lcu.AcceptGame()
+	if err := lcu.AcceptGame(p.ctx); err != nil {
+		logger.Info("自动接受对局失败", zap.Error(err))
+	}
 }
 
 func (p Prophet) CalcEnemyTeamScore() {
 	// 获取当前游戏进程
-	session, err := lcu.QueryGameFlowSession()
+	session, err := lcu.QueryGameFlowSession(p.ctx)
 	if err != nil {
 		return
 	}
 	if session.Phase != models.GameFlowInProgress {
 		return
 	}
-	if p.currSummoner == nil {
+	currSummoner := p.getCurrSummoner()
+	if currSummoner == nil {
 		return
 	}
-	selfID := p.currSummoner.SummonerId
-	selfTeamUsers, enemyTeamUsers := getAllUsersFromSession(selfID, session)
-	_ = selfTeamUsers
-	summonerIDList := enemyTeamUsers
+	selfTeamUsers, enemyTeamUsers := getAllUsersFromSession(currSummoner.Puuid, session)
 
-	logger.Debug("敌方队伍人员列表:", zap.Any("summonerIDList", summonerIDList))
-	if len(summonerIDList) == 0 {
+	logger.Debug("敌方队伍人员列表:", zap.Any("puuidList", enemyTeamUsers))
+	if len(enemyTeamUsers) == 0 {
 		return
 	}
+	scoreCfg := global.GetScoreConf()
+	puuidList := append([]string(nil), enemyTeamUsers...)
+	puuidMapScore := map[string]lcu.UserScore{}
+	// 我方得分只用于胜率预估,优先使用选人阶段已算出的得分
+	if scoreCfg.WinProbability.Enabled {
+		puuidMapScore = p.champSelect.cachedScores(selfTeamUsers)
+		for _, puuid := range selfTeamUsers {
+			if _, ok := puuidMapScore[puuid]; !ok {
+				puuidList = append(puuidList, puuid)
+			}
+		}
+	}
+	modeName := scoreModeOf(session)
+	champions := championsFromSession(session)
 	// 查询所有用户的信息并计算得分
 	g := errgroup.Group{}
-	summonerIDMapScore := map[int64]lcu.UserScore{}
 	mu := sync.Mutex{}
-	for _, summonerID := range summonerIDList {
-		summonerID := summonerID
+	for _, puuid := range puuidList {
+		puuid := puuid
 		g.Go(func() error {
-			actScore, err := GetUserScore(summonerID)
+			actScore, err := GetUserScore(p.ctx, puuid, modeName)
 			if err != nil {
-				logger.Error("计算用户得分失败", zap.Error(err), zap.Int64("summonerID", summonerID))
+				logger.Error("计算用户得分失败", zap.Error(err), zap.String("puuid", puuid))
 				return nil
 			}
 			mu.Lock()
-			summonerIDMapScore[summonerID] = *actScore
+			puuidMapScore[puuid] = *actScore
 			mu.Unlock()
 			return nil
 		})
 	}
 	_ = g.Wait()
 	// 根据所有用户的分数判断小代上等马中等马下等马
-	for _, score := range summonerIDMapScore {
+	for _, score := range puuidMapScore {
 		currKDASb := strings.Builder{}
 		for i := 0; i < 5 && i < len(score.CurrKDA); i++ {
 			currKDASb.WriteString(fmt.Sprintf("%d/%d/%d  ", score.CurrKDA[i][0], score.CurrKDA[i][1],
@@ -417,14 +539,17 @@ func (p Prophet) CalcEnemyTeamScore() {
 		}
 	}
 	clientCfg := global.GetClientConf()
-	scoreCfg := global.GetScoreConf()
 	allMsg := ""
 	// 发送到选人界面
-	for _, scoreInfo := range summonerIDMapScore {
+	for _, puuid := range enemyTeamUsers {
+		scoreInfo, ok := puuidMapScore[puuid]
+		if !ok {
+			continue
+		}
 		time.Sleep(time.Second / 2)
 		var horse string
 		// horseIdx := 0
-		for i, v := range scoreCfg.Horse {
+		for i, v := range scoreCfg.HorseOf(scoreCfg.ModeByName(scoreInfo.Mode)) {
 			if scoreInfo.Score >= v.Score {
 				horse = clientCfg.HorseNameConf[i]
 				// horseIdx = i
@@ -440,13 +565,37 @@ func (p Prophet) CalcEnemyTeamScore() {
 		if len(currKDAMsg) > 0 {
 			currKDAMsg = currKDAMsg[:len(currKDAMsg)-1]
 		}
-		msg := fmt.Sprintf("敌方%s：%s 得分：%.1f  近期KDA：%s", horse, scoreInfo.SummonerName, scoreInfo.Score, currKDAMsg)
-		Append(msg)
+		msg := fmt.Sprintf("敌方%s%s：%s 得分：%.1f%s%s%s  近期KDA：%s", horse, accountTag(scoreInfo.Account),
+			scoreInfo.SummonerName, scoreInfo.Score, modeFallbackTag(scoreInfo.ModeFallback),
+			laningString(scoreInfo.Laning), badgesString(scoreInfo.Badges)+streakString(scoreInfo.Streak), currKDAMsg)
+		if championID := champions[puuid]; championID > 0 {
+			msg += "  本局英雄：" + championString(scoreInfo.ChampionStat(championID))
+		}
+		p.opts.output(msg)
+		allMsg += msg + "\n"
+	}
+	if msg, ok := p.outputWinEstimate(teamScores(puuidMapScore, selfTeamUsers),
+		teamScores(puuidMapScore, enemyTeamUsers)); ok {
 		allMsg += msg + "\n"
 	}
 	_ = clipboard.WriteAll(allMsg)
 }
 
+// outputWinEstimate 输出并广播我方胜率预估,theirs 为空时对方按基准分计算
+func (p Prophet) outputWinEstimate(ours []float64, theirs []float64) (string, bool) {
+	estimate, ok := scorer.EstimateWin(ours, theirs, global.GetScoreConf().WinProbability)
+	if !ok {
+		return "", false
+	}
+	msg := winEstimateString(estimate)
+	p.opts.output(msg)
+	ws.BroadcastMsg(ws.Msg{
+		Type: ws.MsgTypeWinProbability,
+		Data: estimate,
+	})
+	return msg, true
+}
+
 func (p Prophet) onChampSelectSessionUpdate(sessionInfo *lcu.ChampSelectSessionInfo) error {
 	isSelfPick := false
 	isSelfBan := false
@@ -473,65 +622,317 @@ loop:
 	//Append("AutoPickChampID:", clientCfg.AutoPickChampID)
 	//Append("userActionID:", userActionID)
 	if clientCfg.AutoPickChampID > 0 && isSelfPick {
-		_ = lcu.PickChampion(clientCfg.AutoPickChampID, userActionID)
+		if err := lcu.PickChampion(p.ctx, clientCfg.AutoPickChampID, userActionID); err != nil {
+			logger.Info("自动选择英雄失败", zap.Error(err))
+		}
 	}
 	if clientCfg.AutoBanChampID > 0 && isSelfBan {
-		_ = lcu.BanChampion(clientCfg.AutoBanChampID, userActionID)
+		if err := lcu.BanChampion(p.ctx, clientCfg.AutoBanChampID, userActionID); err != nil {
+			logger.Info("自动禁用英雄失败", zap.Error(err))
+		}
 	}
+	p.outputChampionPicks(sessionInfo)
 	return nil
 }
 
-func (p Prophet) UpdateClientConf(conf *conf.Client) error {
-	cfg := global.SetClientConf(conf)
+// outputChampionPicks 队友预选或锁定的英雄变化时输出其该英雄的近期战绩
+func (p Prophet) outputChampionPicks(sessionInfo *lcu.ChampSelectSessionInfo) {
+	cellPuuid := make(map[int]string, len(sessionInfo.MyTeam))
+	for _, member := range sessionInfo.MyTeam {
+		cellPuuid[member.CellId] = member.Puuid
+	}
+	picks := make(map[string]championPick, len(cellPuuid))
+	for _, actions := range sessionInfo.Actions {
+		for _, action := range actions {
+			puuid, ok := cellPuuid[action.ActorCellId]
+			if !ok || action.Type != lcu.ChampSelectPatchTypePick || action.ChampionId == 0 {
+				continue
+			}
+			picks[puuid] = championPick{championID: action.ChampionId, locked: action.Completed}
+		}
+	}
+	for _, msg := range p.champSelect.newPicks(picks) {
+		p.opts.output(msg)
+	}
+}
+
+// reset 进入选人阶段时清空,得分算出后保存得分
+func (s *champSelectState) reset(scores map[string]lcu.UserScore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scores = scores
+	s.shown = make(map[string]championPick)
+}
+
+// cachedScores 选人阶段已算出的玩家得分
+func (s *champSelectState) cachedScores(puuidList []string) map[string]lcu.UserScore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scores := make(map[string]lcu.UserScore, len(puuidList))
+	for _, puuid := range puuidList {
+		if score, ok := s.scores[puuid]; ok {
+			scores[puuid] = score
+		}
+	}
+	return scores
+}
+
+// newPicks 与上次提示相比有变化且已算出得分的队友的英雄战绩
+func (s *champSelectState) newPicks(picks map[string]championPick) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := make([]string, 0, len(picks))
+	for puuid, pick := range picks {
+		scoreInfo, ok := s.scores[puuid]
+		if !ok || s.shown[puuid] == pick {
+			continue
+		}
+		s.shown[puuid] = pick
+		action := "预选"
+		if pick.locked {
+			action = "锁定"
+		}
+		msgs = append(msgs, fmt.Sprintf("%s %s英雄：%s", scoreInfo.SummonerName, action,
+			championString(scoreInfo.ChampionStat(pick.championID))))
+	}
+	sort.Strings(msgs)
+	return msgs
+}
+
+func (p Prophet) UpdateClientConf(clientConf *conf.Client) error {
+	if err := conf.ValidClientConf(clientConf); err != nil {
+		return err
+	}
+	cfg := global.SetClientConf(clientConf)
 	bts, _ := json.Marshal(cfg)
 	m := enity.Config{}
 	return m.Update(enity.LocalClientConfKey, string(bts))
 }
 
-func (p Prophet) queryBySummonerName(player string) (string, float64, string, string, error) {
-	summonerName := strings.TrimSpace(player)
-	var summonerID int64 = 0
-	var (
-		name  = ""
-		score = 0.0
-		kda   = ""
-		horse = ""
-	)
+// UpdateScoreConf 校验并保存评分配置,立即生效
+func (p Prophet) UpdateScoreConf(scoreConf *conf.CalcScoreConf) error {
+	if err := conf.ValidScoreConf(scoreConf); err != nil {
+		return err
+	}
+	bts, _ := json.Marshal(scoreConf)
+	m := enity.Config{}
+	if err := m.Upsert(enity.LocalScoreConfKey, string(bts)); err != nil {
+		return err
+	}
+	global.SetScoreConf(*scoreConf)
+	return nil
+}
 
+func (p Prophet) queryBySummonerName(player string) (*lcu.UserScore, string, error) {
+	summonerName := strings.TrimSpace(player)
+	var puuid, name string
 	if summonerName == "" {
-		if p.currSummoner == nil {
-			err := errors.New("系统错误")
-			return name, score, kda, horse, err
+		currSummoner := p.getCurrSummoner()
+		if currSummoner == nil {
+			return nil, "", errors.New("系统错误")
 		}
 		// 如果为空，查询自己的分数
-		summonerID = p.currSummoner.SummonerId
-		name = p.currSummoner.DisplayName
+		puuid = currSummoner.Puuid
+		name = currSummoner.RiotID()
 	} else {
-		info, err := lcu.QuerySummonerByName(summonerName)
-		if err != nil || info.SummonerId <= 0 {
-			err = errors.New("未查询到召唤师")
-			return name, score, kda, horse, err
+		info, err := lcu.SearchSummoner(p.ctx, summonerName)
+		if err != nil {
+			return nil, "", searchSummonerErr(summonerName, err)
 		}
-		summonerID = info.SummonerId
-		name = summonerName
+		puuid = info.Puuid
+		name = info.RiotID()
 	}
-	scoreInfo, err := GetUserScore(summonerID)
+	scoreInfo, err := GetUserScore(p.ctx, puuid, p.currentScoreMode())
 	if err != nil {
-		err = errors.New("系统错误")
-		return name, score, kda, horse, err
+		return nil, "", errors.New("系统错误")
 	}
-	score = scoreInfo.Score
-	kda = kdaString(scoreInfo.CurrKDA, 5)
+	scoreInfo.SummonerName = name
+	return scoreInfo, horseName(scoreInfo.Score, scoreInfo.Mode), nil
+}
+
+// currentScoreMode 当前对局对应的评分模式,不在对局中时为空
+func (p Prophet) currentScoreMode() string {
+	session, err := lcu.QueryGameFlowSession(p.ctx)
+	if err != nil {
+		return ""
+	}
+	return scoreModeOf(session)
+}
 
+// scoreModeOf 游戏会话所在队列对应的评分模式,无法识别时为空
+func scoreModeOf(session *lcu.GameFlowSession) string {
+	queue := session.GameData.Queue
+	mode := global.GetScoreConf().ModeOf(int(queue.Id), string(queue.GameMode))
+	if mode == nil {
+		return ""
+	}
+	return mode.Name
+}
+
+// horseName 分数对应的马匹名称,使用游戏模式的马匹分数线
+func horseName(score float64, modeName string) string {
 	scoreCfg := global.GetScoreConf()
 	clientCfg := global.GetClientConf()
-	for i, v := range scoreCfg.Horse {
-		if scoreInfo.Score >= v.Score {
-			horse = clientCfg.HorseNameConf[i]
-			break
+	for i, v := range scoreCfg.HorseOf(scoreCfg.ModeByName(modeName)) {
+		if score >= v.Score {
+			return clientCfg.HorseNameConf[i]
+		}
+	}
+	return ""
+}
+
+// laningString 对线得分,没有对线数据时为空
+func laningString(laning *float64) string {
+	if laning == nil {
+		return ""
+	}
+	return fmt.Sprintf(" 对线：%+.1f", *laning)
+}
+
+// badgesString 行为标记,如 [挂机x2 送人头x3],没有标记时为空
+func badgesString(badges []lcu.Badge) string {
+	if len(badges) == 0 {
+		return ""
+	}
+	list := make([]string, 0, len(badges))
+	for _, badge := range badges {
+		list = append(list, fmt.Sprintf("%sx%d", badge.Behavior, badge.Games))
+	}
+	return fmt.Sprintf(" [%s]", strings.Join(list, " "))
+}
+
+// accountTag 可能性达到阈值时显示在马匹后面,如 (疑似小号75%)
+func accountTag(account *lcu.AccountCheck) string {
+	if account == nil {
+		return ""
+	}
+	threshold := global.GetScoreConf().Smurf.Threshold
+	tags := make([]string, 0, 2)
+	if account.Smurf >= threshold {
+		tags = append(tags, fmt.Sprintf("疑似小号%.0f%%", account.Smurf*100))
+	}
+	if account.Boosted >= threshold {
+		tags = append(tags, fmt.Sprintf("疑似代练/共享%.0f%%", account.Boosted*100))
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%s)", strings.Join(tags, " "))
+}
+
+// notableStreak 连胜连败达到该局数时才在选人消息中显示
+const notableStreak = 3
+
+// streakString 选人消息中的连胜连败及上头标记,不明显时为空
+func streakString(streak *lcu.Streak) string {
+	if streak == nil {
+		return ""
+	}
+	msg := ""
+	if streak.Wins >= notableStreak {
+		msg += fmt.Sprintf(" 连胜%d", streak.Wins)
+	} else if streak.Losses >= notableStreak {
+		msg += fmt.Sprintf(" 连败%d", streak.Losses)
+	}
+	if streak.Tilt {
+		msg += " 上头"
+	}
+	return msg
+}
+
+// streakDetail 近期胜率、连胜连败、本次游戏局数以及上头标记
+func streakDetail(streak *lcu.Streak) string {
+	if streak == nil || streak.RecentGames == 0 {
+		return ""
+	}
+	msg := fmt.Sprintf("近%d局胜率%.0f%%", streak.RecentGames, float64(streak.RecentWins)*100/float64(streak.RecentGames))
+	if streak.Wins > 0 {
+		msg += fmt.Sprintf(" 连胜%d", streak.Wins)
+	} else if streak.Losses > 0 {
+		msg += fmt.Sprintf(" 连败%d", streak.Losses)
+	}
+	msg += fmt.Sprintf(" 本次已玩%d局", streak.SessionGames)
+	if streak.Tilt {
+		msg += " 上头"
+	}
+	return msg
+}
+
+// modelScoresString 各评分模型的得分及对应的马匹,默认算法排在最前
+func modelScoresString(modelScores map[string]float64, modeName string) string {
+	names := make([]string, 0, len(modelScores))
+	for name := range modelScores {
+		if name != conf.DefaultScorerName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := modelScores[conf.DefaultScorerName]; ok {
+		names = append([]string{conf.DefaultScorerName}, names...)
+	}
+	sb := strings.Builder{}
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("%s %.1f(%s)  ", name, modelScores[name], horseName(modelScores[name], modeName)))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// modeFallbackTag 当前模式没有对局时提示得分来自全部模式
+func modeFallbackTag(fallback bool) string {
+	if !fallback {
+		return ""
+	}
+	return "(无本模式对局,按全部模式计算)"
+}
+
+// championString 玩家某个英雄的近期战绩,近期没有用过时提示首次使用
+func championString(stat *lcu.ChampionStat) string {
+	if stat == nil || stat.Games == 0 {
+		return "近期首次使用"
+	}
+	msg := fmt.Sprintf("近期%d局 胜率%.0f%% 场均%.1f/%.1f/%.1f", stat.Games, float64(stat.Wins)*100/float64(stat.Games),
+		stat.KDA[0], stat.KDA[1], stat.KDA[2])
+	if stat.ScoredGames > 0 {
+		msg += fmt.Sprintf(" 平均得分%.1f", stat.Score)
+	}
+	return msg
+}
+
+// teamScores 队伍中已计算出得分的玩家得分
+func teamScores(puuidMapScore map[string]lcu.UserScore, puuidList []string) []float64 {
+	scores := make([]float64, 0, len(puuidList))
+	for _, puuid := range puuidList {
+		if score, ok := puuidMapScore[puuid]; ok {
+			scores = append(scores, score.Score)
+		}
+	}
+	return scores
+}
+
+// winEstimateString 胜率预估消息
+func winEstimateString(estimate *scorer.WinEstimate) string {
+	if !estimate.TheirKnown {
+		return fmt.Sprintf("我方胜率预估 %.0f%%（我方均分%.1f，对方未知）", estimate.Probability*100, estimate.OurScore)
+	}
+	return fmt.Sprintf("我方胜率预估 %.0f%%（我方均分%.1f，对方均分%.1f）", estimate.Probability*100,
+		estimate.OurScore, estimate.TheirScore)
+}
+
+// searchSummonerErr 转换为界面展示的搜索错误
+func searchSummonerErr(input string, err error) error {
+	var ambiguousErr *lcu.AmbiguousSummonerError
+	switch {
+	case errors.As(err, &ambiguousErr):
+		return errors.Errorf("%s,请输入完整的 名称#编号", ambiguousErr.Error())
+	case errors.Is(err, lcu.ErrSummonerNotFound):
+		if _, tagLine := lcu.ParseRiotID(input); tagLine == "" {
+			return errors.Errorf("未查询到召唤师 %s,新版客户端请输入 名称#编号", input)
 		}
+		return errors.Errorf("未查询到召唤师 %s", input)
+	case lcu.IsClientGone(err):
+		return errors.New("未连接lol客户端")
 	}
-	return name, score, kda, horse, err
+	return errors.Wrap(err, "查询召唤师失败")
 }
 
 func kdaString(currKDA [][3]int, n int) string {
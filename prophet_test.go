@@ -0,0 +1,271 @@
+package lol_prophet_gui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/global"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/lcutest"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+type testOutput struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (o *testOutput) append(text ...interface{}) {
+	o.mu.Lock()
+	o.lines = append(o.lines, strings.TrimSuffix(fmt.Sprintln(text...), "\n"))
+	o.mu.Unlock()
+}
+
+func (o *testOutput) wait(t *testing.T, substr string, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		o.mu.Lock()
+		for _, line := range o.lines {
+			if strings.Contains(line, substr) {
+				o.mu.Unlock()
+				return
+			}
+		}
+		o.mu.Unlock()
+		time.Sleep(time.Millisecond * 20)
+	}
+	t.Fatalf("等待输出超时: %s", substr)
+}
+
+// newTestProphet 启动假lcu服务并连接, 本方为 1-5, 敌方为 6-10
+func newTestProphet(t *testing.T) (*Prophet, *lcutest.Server, *testOutput) {
+	t.Helper()
+	*global.Conf = global.DefaultAppConf
+	global.Conf.CalcScore.MergeMsg = true
+	clientConf := global.DefaultClientConf
+	clientConf.AutoAcceptGame = true
+	clientConf.AutoSendTeamHorse = true
+	clientConf.ChooseChampSendMsgDelaySec = 0
+	*global.ClientConf = clientConf
+
+	srv := lcutest.NewServer()
+	t.Cleanup(srv.Close)
+	self := lcutest.NewPlayer(1, models.TeamIDBlue)
+	srv.SetCurrentSummoner(self)
+	ids := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	players := make([]lcutest.Player, 0, len(ids))
+	for _, id := range ids {
+		players = append(players, lcutest.NewPlayer(id, models.TeamIDBlue))
+	}
+	srv.AddSummoners(players...)
+	now := time.Now()
+	srv.SetGames(
+		lcutest.NewGame(1001, now.Add(-time.Hour), ids...),
+		lcutest.NewGame(1002, now.Add(-time.Hour*24), ids...),
+	)
+	srv.AcceptReadyCheck()
+	srv.SetChampSelectConversation(ids[:5]...)
+	srv.SetGameFlowSession(models.GameFlowInProgress, ids[:5], ids[5:])
+
+	global.Conf.Lcu.Discovery = []string{lcu.DiscoverTypeEnv}
+	global.Conf.Lcu.Port = srv.Port()
+	global.Conf.Lcu.Token = srv.Token
+	output := &testOutput{}
+	p := NewProphet(WithOutput(output.append))
+	return p, srv, output
+}
+
+func TestProphetGameLifecycle(t *testing.T) {
+	p, srv, output := newTestProphet(t)
+	monitorDone := make(chan struct{})
+	go func() {
+		p.MonitorStart()
+		close(monitorDone)
+	}()
+	if err := srv.WaitSubscribed(time.Second * 5); err != nil {
+		t.Fatal(err)
+	}
+	if p.ConnState() != ConnStateSubscribed {
+		t.Fatalf("conn state %s", p.ConnState())
+	}
+	if summoner := p.getCurrSummoner(); summoner == nil || summoner.Puuid != "puuid-1" {
+		t.Fatalf("curr summoner %+v", summoner)
+	}
+
+	if err := srv.PushGameFlow(string(models.GameFlowReadyCheck)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.WaitRequest(http.MethodPost, "/lol-matchmaking/v1/ready-check/accept", time.Second*5); err != nil {
+		t.Fatal(err)
+	}
+
+	myTeam := make([]map[string]interface{}, 0, 5)
+	for i := 1; i <= 5; i++ {
+		myTeam = append(myTeam, map[string]interface{}{"cellId": i - 1, "puuid": fmt.Sprintf("puuid-%d", i)})
+	}
+	srv.SetChampSelectSession(map[string]interface{}{
+		"localPlayerCellId": 0,
+		"myTeam":            myTeam,
+		"actions": [][]map[string]interface{}{{
+			{"actorCellId": 1, "championId": 99, "type": lcu.ChampSelectPatchTypePick},
+			{"actorCellId": 2, "championId": 4, "type": lcu.ChampSelectPatchTypePick, "completed": true},
+		}},
+	})
+	if err := srv.PushGameFlow(string(models.GameFlowChampionSelect)); err != nil {
+		t.Fatal(err)
+	}
+	output.wait(t, "本局", time.Second*10)
+	// 队友3 近期两局都用的4号英雄,队友2 没有用过99号英雄
+	output.wait(t, "player3#test 锁定英雄：近期2局", time.Second*5)
+	output.wait(t, "player2#test 预选英雄：近期首次使用", time.Second*5)
+	deadline := time.Now().Add(time.Second * 5)
+	for len(srv.ChatMessages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 20)
+	}
+	msgs := srv.ChatMessages()
+	if len(msgs) != 1 || strings.Count(msgs[0], "得分") != 5 {
+		t.Fatalf("选人阶段消息错误: %v", msgs)
+	}
+	if len(srv.Requests(http.MethodGet, "/lol-match-history/v1/products/lol/puuid-3/matches")) == 0 {
+		t.Error("应通过puuid查询战绩")
+	}
+	if !strings.Contains(msgs[0], "player3#test") {
+		t.Errorf("消息中应使用Riot ID: %s", msgs[0])
+	}
+
+	selfHistoryPath := "/lol-match-history/v1/products/lol/puuid-3/matches"
+	selfHistoryRequests := len(srv.Requests(http.MethodGet, selfHistoryPath))
+	if err := srv.PushGameFlow(string(models.GameFlowInProgress)); err != nil {
+		t.Fatal(err)
+	}
+	output.wait(t, "敌方", time.Second*10)
+	output.wait(t, "对方均分", time.Second*10)
+	// 我方得分沿用选人阶段的结果
+	if n := len(srv.Requests(http.MethodGet, selfHistoryPath)); n != selfHistoryRequests {
+		t.Errorf("游戏中不应重新查询队友战绩: %d -> %d", selfHistoryRequests, n)
+	}
+	if p.getGameState() != GameStateInGame {
+		t.Errorf("game state %s", p.getGameState())
+	}
+
+	_ = p.Stop()
+	select {
+	case <-monitorDone:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Stop 之后监控未退出")
+	}
+	if p.ConnState() != ConnStateLost {
+		t.Errorf("conn state %s", p.ConnState())
+	}
+}
+
+func TestChampionSelectWaitsForFullTeam(t *testing.T) {
+	p, srv, _ := newTestProphet(t)
+	lcu.InitCli(srv.Port(), srv.Token)
+	ids := []int64{1, 2, 3, 4, 5}
+	// 第一次查询时只有3名队友进入聊天组,之后全部进入
+	srv.SetChampSelectConversation(ids[:3]...)
+	msgPath := fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", lcutest.ChampSelectConversationID)
+	go func() {
+		if _, err := srv.WaitRequest(http.MethodGet, msgPath, time.Second*5); err == nil {
+			srv.SetChampSelectConversation(ids...)
+		}
+	}()
+	p.ChampionSelectStart()
+	msgs := srv.ChatMessages()
+	if len(srv.Requests(http.MethodGet, msgPath)) < 2 || len(msgs) != 1 || strings.Count(msgs[0], "得分") != 5 {
+		t.Fatalf("队友未全部进入时应重新查询: %v", msgs)
+	}
+}
+
+func TestGetUserScoreBreakdown(t *testing.T) {
+	_, srv, _ := newTestProphet(t)
+	lcu.InitCli(srv.Port(), srv.Token)
+	scoreInfo, err := GetUserScore(context.Background(), "puuid-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scoreInfo.Games) != 2 || scoreInfo.Games[0].GameID != 1001 || scoreInfo.Games[1].GameID != 1002 {
+		t.Fatalf("对局应按时间倒序: %+v", scoreInfo.Games)
+	}
+	for _, game := range scoreInfo.Games {
+		total := 100.0
+		for _, reason := range game.Reasons {
+			total += reason.IncVal
+		}
+		if len(game.Reasons) == 0 || math.Abs(total-game.Score) > 1e-9 || game.KDA != [3]int{5, 5, 5} {
+			t.Errorf("得分明细与得分不一致: %+v", game)
+		}
+	}
+	text := playerDetailText(scoreInfo, horseName(scoreInfo.Score, scoreInfo.Mode))
+	if !strings.Contains(text, string(lcu.ScoreOptionJoinTeamRateRank)) || !strings.Contains(text, "汗血宝马分数线") {
+		t.Errorf("bad detail text:\n%s", text)
+	}
+	// 大乱斗没有对局时使用全部模式的对局,按全局分数线判断
+	scoreInfo, err = GetUserScore(context.Background(), "puuid-1", "aram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !scoreInfo.ModeFallback || scoreInfo.Mode != "" || len(scoreInfo.Games) != 2 {
+		t.Errorf("应标记为使用全部模式: mode %q fallback %v games %d", scoreInfo.Mode, scoreInfo.ModeFallback,
+			len(scoreInfo.Games))
+	}
+}
+
+func TestGetUserScoreBadgesFromShortGames(t *testing.T) {
+	_, srv, _ := newTestProphet(t)
+	lcu.InitCli(srv.Port(), srv.Token)
+	ids := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	now := time.Now()
+	games := []lcutest.Game{
+		lcutest.NewGame(3001, now.Add(-time.Hour), ids...),
+		lcutest.NewGame(3002, now.Add(-time.Hour*2), ids...),
+	}
+	// 两局6分钟提前投降的对局,时长不足不参与评分
+	for i := 0; i < 2; i++ {
+		g := lcutest.NewGame(int64(3003+i), now.Add(-time.Hour*time.Duration(3+i)), ids...)
+		g.Duration = 6 * 60
+		g.Players[0].CausedEarlySurrender = true
+		games = append(games, g)
+	}
+	srv.SetGames(games...)
+	scoreInfo, err := GetUserScore(context.Background(), "puuid-1", "rift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scoreInfo.Games) != 2 {
+		t.Errorf("短对局不应参与评分, got %d", len(scoreInfo.Games))
+	}
+	if len(scoreInfo.Badges) != 1 || scoreInfo.Badges[0] != (lcu.Badge{Behavior: lcu.BehaviorSurrender, Games: 2}) {
+		t.Errorf("应根据短对局标记发起投降, got %+v", scoreInfo.Badges)
+	}
+}
+
+func TestGameHistoryPaging(t *testing.T) {
+	_, srv, _ := newTestProphet(t)
+	lcu.InitCli(srv.Port(), srv.Token)
+	ids := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	games := make([]lcutest.Game, 0, 30)
+	for i := 0; i < 30; i++ {
+		games = append(games, lcutest.NewGame(int64(2000+i), time.Now().Add(-time.Hour*time.Duration(i+1)), ids...))
+	}
+	srv.SetGames(games...)
+	global.Conf.CalcScore.Weighting.HistoryDepth = 25
+	gameList, _, _, err := listGameHistory(context.Background(), "puuid-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gameList) != 25 {
+		t.Errorf("应获取25局战绩, got %d", len(gameList))
+	}
+	if n := len(srv.Requests(http.MethodGet, "/lol-match-history/v1/products/lol/puuid-1/matches")); n != 2 {
+		t.Errorf("应分2页查询, got %d", n)
+	}
+}
@@ -0,0 +1,95 @@
+package lol_prophet_gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/global"
+	"io"
+)
+
+// showScoreSettings 评分设置页面,以json编辑评分配置,支持导入导出预设
+func (g *gui) showScoreSettings(app fyne.App) {
+	w := app.NewWindow("评分设置")
+	editor := widget.NewMultiLineEntry()
+	editor.TextStyle.Monospace = true
+	setConf := func(scoreConf *conf.CalcScoreConf) {
+		bts, _ := conf.MarshalScoreConf(scoreConf)
+		editor.SetText(string(bts))
+	}
+	setConf(global.GetScoreConf())
+	parse := func() (*conf.CalcScoreConf, bool) {
+		scoreConf, err := conf.ParseScoreConf([]byte(editor.Text), *global.GetScoreConf())
+		if err != nil {
+			dialog.ShowError(err, w)
+			return nil, false
+		}
+		return scoreConf, true
+	}
+
+	buttons := container.NewGridWithColumns(4,
+		widget.NewButton("保存", func() {
+			scoreConf, ok := parse()
+			if !ok {
+				return
+			}
+			if err := g.p.UpdateScoreConf(scoreConf); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			setConf(scoreConf)
+			Append("评分配置已保存")
+			dialog.ShowInformation("评分设置", "保存成功", w)
+		}),
+		widget.NewButton("导入预设", func() {
+			fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+				if err != nil || reader == nil {
+					return
+				}
+				defer reader.Close()
+				bts, err := io.ReadAll(reader)
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				scoreConf, err := conf.ParseScoreConf(bts, global.DefaultAppConf.CalcScore)
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				// 导入后需要点击保存才会生效
+				setConf(scoreConf)
+			}, w)
+			fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+			fd.Show()
+		}),
+		widget.NewButton("导出预设", func() {
+			scoreConf, ok := parse()
+			if !ok {
+				return
+			}
+			fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+				if err != nil || writer == nil {
+					return
+				}
+				defer writer.Close()
+				bts, _ := conf.MarshalScoreConf(scoreConf)
+				if _, err = writer.Write(bts); err != nil {
+					dialog.ShowError(err, w)
+				}
+			}, w)
+			fd.SetFileName("score-preset.json")
+			fd.Show()
+		}),
+		widget.NewButton("恢复默认", func() {
+			defaultConf := global.DefaultAppConf.CalcScore
+			setConf(&defaultConf)
+		}),
+	)
+	w.SetContent(container.NewBorder(nil, buttons, nil, nil, container.NewScroll(editor)))
+	w.Resize(resize(600, 700))
+	w.Show()
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/beastars1/lol-prophet-gui/global"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
@@ -13,13 +14,15 @@ type (
 		Key string          `json:"key" gorm:"column:k"`
 		Val string          `json:"val" gorm:"column:v"`
 		Ctx context.Context `json:"-" gorm:"-"`
+		DB  *gorm.DB        `json:"-" gorm:"-"` // 为空时使用 global.SqliteDB
 	}
 )
 
 const (
-	LocalClientConfKey = "localClient"
-	InitLocalClientSql = `
-create table config
+	LocalClientConfKey   = "localClient"
+	LocalScoreConfKey    = "calcScore"
+	CreateConfigTableSql = `
+create table if not exists config
 (
     id integer     not null
         constraint config_pk
@@ -27,10 +30,8 @@ create table config
     k  varchar(32) not null,
     v  TEXT        not null
 );
-create unique index config_k_uindex
+create unique index if not exists config_k_uindex
     on config (k);
-INSERT INTO config (k, v)
-VALUES (?, ?);
 `
 )
 
@@ -39,6 +40,9 @@ func (m Config) TableName() string {
 }
 func (m Config) GetGormQuery() *gorm.DB {
 	db := global.SqliteDB
+	if m.DB != nil {
+		db = m.DB
+	}
 	if m.Ctx != nil {
 		db = db.WithContext(m.Ctx)
 	}
@@ -47,3 +51,11 @@ func (m Config) GetGormQuery() *gorm.DB {
 func (m Config) Update(k, v string) error {
 	return m.GetGormQuery().Where("k = ?", k).Update("v", v).Error
 }
+
+// Upsert 不存在时插入,存在时更新
+func (m Config) Upsert(k, v string) error {
+	return m.GetGormQuery().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "k"}},
+		DoUpdates: clause.AssignmentColumns([]string{"v"}),
+	}).Create(&Config{Key: k, Val: v}).Error
+}
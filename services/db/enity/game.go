@@ -0,0 +1,79 @@
+package enity
+
+import (
+	"time"
+)
+
+type (
+	// Game 已结束的对局,summary 为完整的对局详情json
+	Game struct {
+		ID           int64     `json:"id" gorm:"primaryKey;autoIncrement:false"`
+		QueueID      int       `json:"queueID" gorm:"column:queue_id"`
+		GameMode     string    `json:"gameMode" gorm:"column:game_mode"`
+		GameCreation int64     `json:"gameCreation" gorm:"column:game_creation"` // 创建时间戳 ms
+		GameDuration int       `json:"gameDuration" gorm:"column:game_duration"` // 游戏时长 秒
+		Summary      string    `json:"summary" gorm:"column:summary"`
+		CreatedAt    time.Time `json:"createdAt" gorm:"column:created_at"`
+	}
+	// Participant 对局中的一名玩家
+	Participant struct {
+		ID            int64  `json:"id" gorm:"primaryKey"`
+		GameID        int64  `json:"gameID" gorm:"column:game_id"`
+		ParticipantID int    `json:"participantID" gorm:"column:participant_id"`
+		Puuid         string `json:"puuid" gorm:"column:puuid"`
+		SummonerID    int64  `json:"summonerID" gorm:"column:summoner_id"`
+		SummonerName  string `json:"summonerName" gorm:"column:summoner_name"`
+		ChampionID    int    `json:"championID" gorm:"column:champion_id"`
+		TeamID        int    `json:"teamID" gorm:"column:team_id"`
+		Kills         int    `json:"kills" gorm:"column:kills"`
+		Deaths        int    `json:"deaths" gorm:"column:deaths"`
+		Assists       int    `json:"assists" gorm:"column:assists"`
+		Win           bool   `json:"win" gorm:"column:win"`
+	}
+)
+
+const (
+	InitGameSql = `
+create table if not exists games
+(
+    id            integer not null
+        constraint games_pk
+            primary key,
+    queue_id      integer not null,
+    game_mode     varchar(32) not null,
+    game_creation integer not null,
+    game_duration integer not null,
+    summary       TEXT    not null,
+    created_at    datetime not null
+);
+create table if not exists participants
+(
+    id             integer not null
+        constraint participants_pk
+            primary key autoincrement,
+    game_id        integer not null,
+    participant_id integer not null,
+    puuid          varchar(128) not null,
+    summoner_id    integer not null,
+    summoner_name  varchar(64) not null,
+    champion_id    integer not null,
+    team_id        integer not null,
+    kills          integer not null,
+    deaths         integer not null,
+    assists        integer not null,
+    win            integer not null
+);
+create unique index if not exists participants_game_id_participant_id_uindex
+    on participants (game_id, participant_id);
+create index if not exists participants_puuid_index
+    on participants (puuid);
+`
+)
+
+func (m Game) TableName() string {
+	return "games"
+}
+
+func (m Participant) TableName() string {
+	return "participants"
+}
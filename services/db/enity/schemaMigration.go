@@ -0,0 +1,31 @@
+package enity
+
+import (
+	"time"
+)
+
+type (
+	// SchemaMigration 已执行的数据库迁移
+	SchemaMigration struct {
+		Version   int       `json:"version" gorm:"primaryKey;autoIncrement:false"`
+		Name      string    `json:"name" gorm:"column:name"`
+		AppliedAt time.Time `json:"appliedAt" gorm:"column:applied_at"`
+	}
+)
+
+const (
+	CreateSchemaMigrationTableSql = `
+create table if not exists schema_migrations
+(
+    version    integer     not null
+        constraint schema_migrations_pk
+            primary key,
+    name       varchar(64) not null,
+    applied_at datetime    not null
+);
+`
+)
+
+func (m SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/beastars1/lol-prophet-gui/services/db/enity"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrGameNotFound 本地没有该对局
+	ErrGameNotFound = errors.New("本地对局记录不存在")
+)
+
+type (
+	// GameRepo 本地对局记录,对局结束后不会再变化,可以一直使用
+	GameRepo struct {
+		db *gorm.DB
+	}
+)
+
+func NewGameRepo(db *gorm.DB) *GameRepo {
+	return &GameRepo{db: db}
+}
+
+// GetGameSummary 读取本地对局详情,不存在时返回 ErrGameNotFound
+func (r *GameRepo) GetGameSummary(ctx context.Context, gameID int64) (*lcu.GameSummary, error) {
+	game := &enity.Game{}
+	err := r.db.WithContext(ctx).Where("id = ?", gameID).Take(game).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary := &lcu.GameSummary{}
+	if err = json.Unmarshal([]byte(game.Summary), summary); err != nil {
+		return nil, errors.Wrapf(err, "解析本地对局失败 gameID: %d", gameID)
+	}
+	return summary, nil
+}
+
+// SaveGameSummary 保存对局详情及参与者,已存在时忽略
+func (r *GameRepo) SaveGameSummary(ctx context.Context, summary *lcu.GameSummary) error {
+	bts, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	game := &enity.Game{
+		ID:           summary.GameId,
+		QueueID:      summary.QueueId,
+		GameMode:     string(summary.GameMode),
+		GameCreation: summary.GameCreation,
+		GameDuration: summary.GameDuration,
+		Summary:      string(bts),
+		CreatedAt:    time.Now(),
+	}
+	participants := toParticipants(summary)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(game).Error; err != nil {
+			return err
+		}
+		if len(participants) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&participants).Error
+	})
+}
+
+// EachGameSummary 按对局id顺序分批读取本地所有对局详情,fn返回错误时停止
+func (r *GameRepo) EachGameSummary(ctx context.Context, batchSize int, fn func(summary *lcu.GameSummary) error) error {
+	games := make([]enity.Game, 0, batchSize)
+	return r.db.WithContext(ctx).Order("id").FindInBatches(&games, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, game := range games {
+			summary := &lcu.GameSummary{}
+			if err := json.Unmarshal([]byte(game.Summary), summary); err != nil {
+				return errors.Wrapf(err, "解析本地对局失败 gameID: %d", game.ID)
+			}
+			if err := fn(summary); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// ListParticipantsByPuuid 查询玩家本地所有对局中的数据,按对局时间倒序
+func (r *GameRepo) ListParticipantsByPuuid(ctx context.Context, puuid string, limit int) ([]enity.Participant,
+	error) {
+	list := make([]enity.Participant, 0, limit)
+	err := r.db.WithContext(ctx).
+		Joins("join games on games.id = participants.game_id").
+		Where("participants.puuid = ?", puuid).
+		Order("games.game_creation desc").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+func toParticipants(summary *lcu.GameSummary) []enity.Participant {
+	list := make([]enity.Participant, 0, len(summary.Participants))
+	for _, participant := range summary.Participants {
+		item := enity.Participant{
+			GameID:        summary.GameId,
+			ParticipantID: participant.ParticipantId,
+			ChampionID:    participant.ChampionId,
+			TeamID:        int(participant.TeamId),
+			Kills:         participant.Stats.Kills,
+			Deaths:        participant.Stats.Deaths,
+			Assists:       participant.Stats.Assists,
+			Win:           participant.Stats.Win,
+		}
+		for _, identity := range summary.ParticipantIdentities {
+			if identity.ParticipantId == participant.ParticipantId {
+				item.Puuid = identity.Player.Puuid
+				item.SummonerID = identity.Player.SummonerId
+				item.SummonerName = identity.Player.SummonerName
+				break
+			}
+		}
+		list = append(list, item)
+	}
+	return list
+}
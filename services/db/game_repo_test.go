@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/lcutest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "prophet.db")), &gorm.Config{
+		Logger: gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Migrate(db, "", Migrations); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	return db
+}
+
+func TestGameRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewGameRepo(newTestDB(t))
+	if _, err := repo.GetGameSummary(ctx, 1001); err != ErrGameNotFound {
+		t.Fatalf("expect ErrGameNotFound, got %v", err)
+	}
+	now := time.Now()
+	ids := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	older := lcutest.NewGame(1001, now.Add(-time.Hour*24), ids...).Summary()
+	newer := lcutest.NewGame(1002, now.Add(-time.Hour), ids...).Summary()
+	for _, summary := range []*lcu.GameSummary{&older, &newer, &older} {
+		if err := repo.SaveGameSummary(ctx, summary); err != nil {
+			t.Fatal(err)
+		}
+	}
+	summary, err := repo.GetGameSummary(ctx, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.GameId != 1001 || len(summary.Participants) != 10 ||
+		summary.ParticipantIdentities[2].Player.Puuid != "puuid-3" {
+		t.Errorf("bad summary %+v", summary)
+	}
+	list, err := repo.ListParticipantsByPuuid(ctx, "puuid-7", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].GameID != 1002 || list[0].Win {
+		t.Errorf("bad participants %+v", list)
+	}
+}
+
+func TestEachGameSummary(t *testing.T) {
+	ctx := context.Background()
+	repo := NewGameRepo(newTestDB(t))
+	ids := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for i := 0; i < 5; i++ {
+		summary := lcutest.NewGame(int64(1001+i), time.Now(), ids...).Summary()
+		if err := repo.SaveGameSummary(ctx, &summary); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gameIDs := make([]int64, 0, 5)
+	err := repo.EachGameSummary(ctx, 2, func(summary *lcu.GameSummary) error {
+		gameIDs = append(gameIDs, summary.GameId)
+		return nil
+	})
+	if err != nil || len(gameIDs) != 5 || gameIDs[0] != 1001 || gameIDs[4] != 1005 {
+		t.Errorf("EachGameSummary = %v, %v", gameIDs, err)
+	}
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"fmt"
+	"github.com/beastars1/lol-prophet-gui/services/db/enity"
+	"github.com/beastars1/lol-prophet-gui/services/logger"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type (
+	// Migration 一次数据库结构升级,版本号必须递增且不能修改已发布的迁移
+	Migration struct {
+		Version int
+		Name    string
+		Up      func(tx *gorm.DB) error
+	}
+)
+
+// Migrations 所有数据库迁移,新增表或字段时在末尾追加
+var Migrations = []Migration{
+	{Version: 1, Name: "create config", Up: execSql(enity.CreateConfigTableSql)},
+	{Version: 2, Name: "create games", Up: execSql(enity.InitGameSql)},
+}
+
+func execSql(sql string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Exec(sql).Error
+	}
+}
+
+// LatestVersion 迁移列表中的最新版本
+func LatestVersion(migrations []Migration) int {
+	latest := 0
+	for _, m := range migrations {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+// SchemaVersion 当前数据库结构版本,未执行过迁移时为0
+func SchemaVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(enity.SchemaMigration{}.TableName()) {
+		return 0, nil
+	}
+	var version int
+	err := db.Model(&enity.SchemaMigration{}).Select("coalesce(max(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Migrate 按顺序执行未执行的迁移,每个迁移一个事务,升级前备份数据库文件
+func Migrate(db *gorm.DB, dbPath string, migrations []Migration) error {
+	if err := checkMigrations(migrations); err != nil {
+		return err
+	}
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return errors.Wrap(err, "查询数据库版本失败")
+	}
+	latest := LatestVersion(migrations)
+	if current > latest {
+		logger.Info("数据库版本高于程序支持的版本", zap.Int("current", current), zap.Int("latest", latest))
+		return nil
+	}
+	pending := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if dbPath != "" {
+		backupPath, err := backupDBFile(dbPath, current)
+		if err != nil {
+			return errors.Wrap(err, "备份数据库失败")
+		}
+		if backupPath != "" {
+			logger.Info("升级前已备份数据库", zap.String("path", backupPath))
+		}
+	}
+	if err = db.Exec(enity.CreateSchemaMigrationTableSql).Error; err != nil {
+		return err
+	}
+	for _, m := range pending {
+		m := m
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&enity.SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return errors.Wrapf(err, "数据库迁移失败 %d %s", m.Version, m.Name)
+		}
+		logger.Info("数据库迁移完成", zap.Int("version", m.Version), zap.String("name", m.Name))
+	}
+	return nil
+}
+
+func checkMigrations(migrations []Migration) error {
+	for i, m := range migrations {
+		if m.Up == nil {
+			return errors.Errorf("数据库迁移 %d 缺少Up", m.Version)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return errors.Errorf("数据库迁移版本必须递增: %d", m.Version)
+		}
+	}
+	return nil
+}
+
+// backupDBFile 复制数据库文件,文件不存在或为空时不备份
+func backupDBFile(dbPath string, version int) (string, error) {
+	info, err := os.Stat(dbPath)
+	if os.IsNotExist(err) || (err == nil && info.Size() == 0) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	src, err := os.Open(dbPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	backupPath := fmt.Sprintf("%s.v%d.%s.bak", dbPath, version, time.Now().Format("20060102150405"))
+	dst, err := os.Create(backupPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return "", err
+	}
+	return backupPath, dst.Close()
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/beastars1/lol-prophet-gui/services/db/enity"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T, dbPath string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	return db
+}
+
+func TestMigrateFreshDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "prophet.db")
+	db := openTestDB(t, dbPath)
+	for i := 0; i < 2; i++ {
+		if err := Migrate(db, dbPath, Migrations); err != nil {
+			t.Fatal(err)
+		}
+	}
+	version, err := SchemaVersion(db)
+	if err != nil || version != LatestVersion(Migrations) {
+		t.Fatalf("version %d, %v", version, err)
+	}
+	for _, table := range []string{"config", "games", "participants"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s not created", table)
+		}
+	}
+	if backups, _ := filepath.Glob(dbPath + ".*.bak"); len(backups) != 0 {
+		t.Errorf("fresh db should not be backed up: %v", backups)
+	}
+}
+
+func TestMigrateLegacyDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "prophet.db")
+	db := openTestDB(t, dbPath)
+	// 引入迁移之前的数据库只有config表
+	legacySql := `
+create table config
+(
+    id integer     not null
+        constraint config_pk
+            primary key autoincrement,
+    k  varchar(32) not null,
+    v  TEXT        not null
+);
+create unique index config_k_uindex
+    on config (k);
+INSERT INTO config (k, v)
+VALUES (?, ?);
+`
+	if err := db.Exec(legacySql, enity.LocalClientConfKey, `{"autoAcceptGame":true}`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := SchemaVersion(db); version != 0 {
+		t.Fatalf("legacy version %d", version)
+	}
+	if err := Migrate(db, dbPath, Migrations); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := SchemaVersion(db); version != LatestVersion(Migrations) {
+		t.Errorf("version %d", version)
+	}
+	confItem := &enity.Config{}
+	if err := db.Where("k = ?", enity.LocalClientConfKey).First(confItem).Error; err != nil ||
+		confItem.Val != `{"autoAcceptGame":true}` {
+		t.Errorf("config lost: %+v, %v", confItem, err)
+	}
+	backups, _ := filepath.Glob(dbPath + ".v0.*.bak")
+	if len(backups) != 1 {
+		t.Fatalf("expect one backup, got %v", backups)
+	}
+	backupDB := openTestDB(t, backups[0])
+	if backupDB.Migrator().HasTable("games") || !backupDB.Migrator().HasTable("config") {
+		t.Error("backup should be taken before upgrading")
+	}
+}
+
+func TestMigrateRollback(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "prophet.db")
+	db := openTestDB(t, dbPath)
+	migrations := append(append([]Migration{}, Migrations...), Migration{
+		Version: LatestVersion(Migrations) + 1,
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("create table broken (id integer)").Error; err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	})
+	if err := Migrate(db, dbPath, migrations); err == nil {
+		t.Fatal("expect error")
+	}
+	if version, _ := SchemaVersion(db); version != LatestVersion(Migrations) {
+		t.Errorf("version %d", version)
+	}
+	if db.Migrator().HasTable("broken") {
+		t.Error("failed migration should be rolled back")
+	}
+	unordered := []Migration{Migrations[1], Migrations[0]}
+	if err := Migrate(db, dbPath, unordered); err == nil {
+		t.Error("unordered migrations should be rejected")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
 	"github.com/beastars1/lol-prophet-gui/services/logger"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -40,6 +41,8 @@ type (
 	CurrSummoner struct {
 		AccountId                   int64  `json:"accountId"`
 		DisplayName                 string `json:"displayName"`
+		GameName                    string `json:"gameName"`
+		TagLine                     string `json:"tagLine"`
 		InternalName                string `json:"internalName"`
 		NameChangeFlag              bool   `json:"nameChangeFlag"`
 		PercentCompleteForNextLevel int    `json:"percentCompleteForNextLevel"`
@@ -91,6 +94,7 @@ type (
 				ProfileIcon       int    `json:"profileIcon"`       // 头像icon
 				SummonerId        int64  `json:"summonerId"`        // 召唤师id
 				SummonerName      string `json:"summonerName"`      // 召唤师名称
+				Puuid             string `json:"puuid"`             // 玩家puuid
 			} `json:"player"`
 		} `json:"participantIdentities"`
 		Participants []struct { // 参与者详细信息
@@ -255,6 +259,8 @@ type (
 		CommonResp
 		AccountId                   int64  `json:"accountId"`
 		DisplayName                 string `json:"displayName"`
+		GameName                    string `json:"gameName"`
+		TagLine                     string `json:"tagLine"`
 		InternalName                string `json:"internalName"`
 		NameChangeFlag              bool   `json:"nameChangeFlag"`
 		PercentCompleteForNextLevel int    `json:"percentCompleteForNextLevel"`
@@ -444,6 +450,7 @@ type (
 				ProfileIcon       int    `json:"profileIcon"`
 				SummonerId        int64  `json:"summonerId"`
 				SummonerName      string `json:"summonerName"`
+				Puuid             string `json:"puuid"`
 			} `json:"player"`
 		} `json:"participantIdentities"`
 		Participants []Participant `json:"participants"`
@@ -512,19 +519,20 @@ type (
 		// IsSpectating         bool `json:"isSpectating"`
 		LocalPlayerCellId int `json:"localPlayerCellId"`
 		// LockedEventIndex     int  `json:"lockedEventIndex"`
-		// MyTeam               []struct {
-		// 	AssignedPosition    string `json:"assignedPosition"`
-		// 	CellId              int    `json:"cellId"`
-		// 	ChampionId          int    `json:"championId"`
-		// 	ChampionPickIntent  int    `json:"championPickIntent"`
-		// 	EntitledFeatureType string `json:"entitledFeatureType"`
-		// 	SelectedSkinId      int    `json:"selectedSkinId"`
-		// 	Spell1Id            int    `json:"spell1Id"`
-		// 	Spell2Id            int    `json:"spell2Id"`
-		// 	SummonerId          int64  `json:"summonerId"`
-		// 	Team                int    `json:"team"`
-		// 	WardSkinId          int    `json:"wardSkinId"`
-		// } `json:"myTeam"`
+		MyTeam []struct {
+			// AssignedPosition    string `json:"assignedPosition"`
+			CellId     int `json:"cellId"`
+			ChampionId int `json:"championId"`
+			// ChampionPickIntent  int    `json:"championPickIntent"`
+			// EntitledFeatureType string `json:"entitledFeatureType"`
+			Puuid string `json:"puuid"`
+			// SelectedSkinId      int    `json:"selectedSkinId"`
+			// Spell1Id            int    `json:"spell1Id"`
+			// Spell2Id            int    `json:"spell2Id"`
+			SummonerId int64 `json:"summonerId"`
+			// Team                int    `json:"team"`
+			// WardSkinId          int    `json:"wardSkinId"`
+		} `json:"myTeam"`
 		// RecoveryCounter    int  `json:"recoveryCounter"`
 		// RerollsRemaining   int  `json:"rerollsRemaining"`
 		// SkipChampionSelect bool `json:"skipChampionSelect"`
@@ -587,7 +595,7 @@ type (
 		TimeMatchmakingStart    float64          `json:"timeMatchmakingStart,omitempty"`
 		VoterRating             float64          `json:"voterRating,omitempty"`
 		BotSkillLevel           float64          `json:"botSkillLevel,omitempty"`
-		ChampionId              interface{}      `json:"championId"`
+		ChampionId              int              `json:"championId"`
 		Role                    interface{}      `json:"role"`
 		Spell1Id                interface{}      `json:"spell1Id"`
 		Spell2Id                interface{}      `json:"spell2Id"`
@@ -615,6 +623,10 @@ type (
 			// 	Spell2Id             float64 `json:"spell2Id"`
 			// 	SummonerInternalName string  `json:"summonerInternalName"`
 			// } `json:"playerChampionSelections"`
+			Queue struct {
+				Id       models.GameQueueID `json:"id"`
+				GameMode models.GameMode    `json:"gameMode"`
+			} `json:"queue"`
 			// Queue struct {
 			// 	AllowablePremadeSizes   []interface{} `json:"allowablePremadeSizes"`
 			// 	AreFreeChampionsAllowed bool          `json:"areFreeChampionsAllowed"`
@@ -751,13 +763,13 @@ var (
 )
 
 // 获取当前召唤师
-func GetCurrSummoner() (*CurrSummoner, error) {
-	bts, err := cli.httpGet("/lol-summoner/v1/current-summoner")
+func GetCurrSummoner(ctx context.Context) (*CurrSummoner, error) {
+	cli, err := getCli()
 	if err != nil {
 		return nil, err
 	}
 	data := &CurrSummoner{}
-	err = json.Unmarshal(bts, data)
+	err = cli.getJSON(ctx, "/lol-summoner/v1/current-summoner", data)
 	if err != nil {
 		logger.Info("获取当前召唤师失败", zap.Error(err))
 		return nil, err
@@ -769,14 +781,14 @@ func GetCurrSummoner() (*CurrSummoner, error) {
 }
 
 // 获取比赛记录
-func ListGamesBySummonerID(summonerID int64, begin, limit int) (*GameListResp, error) {
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-match-history/v3/matchlist/account/%d?begIndex=%d&endIndex=%d",
-		summonerID, begin, begin+limit))
+func ListGamesByPuuid(ctx context.Context, puuid string, begin, limit int) (*GameListResp, error) {
+	cli, err := getCli()
 	if err != nil {
 		return nil, err
 	}
 	data := &GameListResp{}
-	err = json.Unmarshal(bts, data)
+	err = cli.getJSON(ctx, fmt.Sprintf("/lol-match-history/v1/products/lol/%s/matches?begIndex=%d&endIndex=%d",
+		url.PathEscape(puuid), begin, begin+limit), data)
 	if err != nil {
 		logger.Info("获取比赛记录", zap.Error(err))
 		return nil, err
@@ -785,13 +797,13 @@ func ListGamesBySummonerID(summonerID int64, begin, limit int) (*GameListResp, e
 }
 
 // 获取会话组消息记录
-func ListConversationMsg(conversationID string) ([]ConversationMsg, error) {
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID))
+func ListConversationMsg(ctx context.Context, conversationID string) ([]ConversationMsg, error) {
+	cli, err := getCli()
 	if err != nil {
 		return nil, err
 	}
 	list := make([]ConversationMsg, 0, 10)
-	err = json.Unmarshal(bts, &list)
+	err = cli.getJSON(ctx, fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID), &list)
 	if err != nil {
 		logger.Info("获取会话组消息记录失败", zap.Error(err))
 		return nil, err
@@ -800,13 +812,13 @@ func ListConversationMsg(conversationID string) ([]ConversationMsg, error) {
 }
 
 // 获取当前对局聊天组
-func GetCurrConversationID() (string, error) {
-	bts, err := cli.httpGet("/lol-chat/v1/conversations")
+func GetCurrConversationID(ctx context.Context) (string, error) {
+	cli, err := getCli()
 	if err != nil {
 		return "", err
 	}
 	list := make([]Conversation, 0, 1)
-	err = json.Unmarshal(bts, &list)
+	err = cli.getJSON(ctx, "/lol-chat/v1/conversations", &list)
 	if err != nil {
 		logger.Info("获取当前对局聊天组失败", zap.Error(err))
 		return "", err
@@ -816,11 +828,15 @@ func GetCurrConversationID() (string, error) {
 			return conversation.Id, nil
 		}
 	}
-	return "", errors.New("当前不在英雄选择阶段")
+	return "", ErrNotInChampSelect
 }
 
 // 发送消息到聊天组
-func SendConversationMsg(msg string, conversationID string) error {
+func SendConversationMsg(ctx context.Context, msg string, conversationID string) error {
+	cli, err := getCli()
+	if err != nil {
+		return err
+	}
 	data := struct {
 		Body string `json:"body"`
 		Type string `json:"type"`
@@ -828,56 +844,57 @@ func SendConversationMsg(msg string, conversationID string) error {
 		Body: msg,
 		Type: "chat",
 	}
-	_, err := cli.httpPost(fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID), data)
+	_, err = cli.httpPost(ctx, fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", conversationID), data)
 	return err
 }
 
 // 申请加好友
-func ApplyFriend(summonerID int64) error {
+func ApplyFriend(ctx context.Context, summonerID int64) error {
+	cli, err := getCli()
+	if err != nil {
+		return err
+	}
 	data := struct {
 		ID string `json:"id"`
 	}{
 		ID: strconv.FormatInt(summonerID, 10),
 	}
-	_, err := cli.httpPost("/lol-chat/v1/friend-requests", data)
+	_, err = cli.httpPost(ctx, "/lol-chat/v1/friend-requests", data)
 	return err
 }
 
 // 取消加好友
-func CancelApplyFriend(summonerID int64) error {
-	_, err := cli.httpDel(fmt.Sprintf("/lol-chat/v1/friend-requests/%d", summonerID))
+func CancelApplyFriend(ctx context.Context, summonerID int64) error {
+	cli, err := getCli()
+	if err != nil {
+		return err
+	}
+	_, err = cli.httpDel(ctx, fmt.Sprintf("/lol-chat/v1/friend-requests/%d", summonerID))
 	return err
 }
 
 // 查询用户信息
-func ListSummoner(summonerIDList []int64) ([]Summoner, error) {
+func ListSummoner(ctx context.Context, summonerIDList []int64) ([]Summoner, error) {
+	cli, err := getCli()
+	if err != nil {
+		return nil, err
+	}
 	idStrList := make([]string, 0, len(summonerIDList))
 	for _, id := range summonerIDList {
 		idStrList = append(idStrList, strconv.FormatInt(id, 10))
 	}
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-summoner/v2/summoners?ids=[%s]",
-		strings.Join(idStrList, ",")))
-	if len(bts) > 0 && bts[0] == '[' {
-		list := make([]Summoner, 0, len(summonerIDList))
-		err = json.Unmarshal(bts, &list)
-		if err != nil {
-			logger.Info("查询用户信息失败", zap.Error(err))
-			return nil, err
-		}
-		return list, err
-	}
-	data := &CommonResp{}
-	err = json.Unmarshal(bts, data)
+	list := make([]Summoner, 0, len(summonerIDList))
+	err = cli.getJSON(ctx, fmt.Sprintf("/lol-summoner/v2/summoners?ids=[%s]", strings.Join(idStrList, ",")), &list)
 	if err != nil {
 		logger.Info("查询用户信息失败", zap.Error(err))
 		return nil, err
 	}
-	return nil, errors.New(data.Message)
+	return list, nil
 }
 
 // 查询用户信息
-func QuerySummoner(summonerID int64) (*Summoner, error) {
-	list, err := ListSummoner([]int64{summonerID})
+func QuerySummoner(ctx context.Context, summonerID int64) (*Summoner, error) {
+	list, err := ListSummoner(ctx, []int64{summonerID})
 	if err != nil {
 		return nil, err
 	}
@@ -888,68 +905,215 @@ func QuerySummoner(summonerID int64) (*Summoner, error) {
 }
 
 // 查询对局详情
-func QueryGameSummary(gameID int64) (*GameSummary, error) {
-	_ = queryGameSummaryLimiter.Wait(context.Background())
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-match-history/v1/games/%d", gameID))
+func QueryGameSummary(ctx context.Context, gameID int64) (*GameSummary, error) {
+	cli, err := getCli()
 	if err != nil {
 		return nil, err
 	}
+	// 每次重试前都等待限流,避免重试时突发大量请求
+	policy := cli.retry
+	policy.BeforeAttempt = queryGameSummaryLimiter.Wait
 	data := &GameSummary{}
-	err = json.Unmarshal(bts, data)
+	err = cli.getJSONWithPolicy(ctx, fmt.Sprintf("/lol-match-history/v1/games/%d", gameID), data, policy)
 	if err != nil {
-		logger.Info("查询对局详情失败", zap.Error(err))
-		return nil, err
-	}
-	if data.CommonResp.ErrorCode != "" {
-		return data, errors.New(fmt.Sprintf("查询对局详情失败 :%s ,gameID: %d", data.CommonResp.Message, gameID))
+		logger.Info("查询对局详情失败", zap.Error(err), zap.Int64("gameID", gameID))
+		return nil, errors.Wrapf(err, "查询对局详情失败 gameID: %d", gameID)
 	}
 	return data, nil
 }
 
 // 查询用户信息
-func QuerySummonerByName(name string) (*Summoner, error) {
-	bts, err := cli.httpGet(fmt.Sprintf("/lol-summoner/v1/summoners?name=%s", name))
+func QuerySummonerByName(ctx context.Context, name string) (*Summoner, error) {
+	cli, err := getCli()
 	if err != nil {
 		return nil, err
 	}
 	data := &Summoner{}
-	err = json.Unmarshal(bts, data)
+	err = cli.getJSON(ctx, fmt.Sprintf("/lol-summoner/v1/summoners?name=%s", escapeQuery(name)), data)
 	if err != nil {
 		logger.Info("搜索用户失败", zap.Error(err))
 		return nil, err
 	}
-	if data.CommonResp.ErrorCode != "" {
-		return nil, errors.New(fmt.Sprintf("搜索用户失败 :%s", data.CommonResp.Message))
+	return data, nil
+}
+
+// 根据puuid查询用户信息
+func QuerySummonerByPuuid(ctx context.Context, puuid string) (*Summoner, error) {
+	cli, err := getCli()
+	if err != nil {
+		return nil, err
+	}
+	data := &Summoner{}
+	err = cli.getJSON(ctx, fmt.Sprintf("/lol-summoner/v2/summoners/puuid/%s", url.PathEscape(puuid)), data)
+	if err != nil {
+		logger.Info("根据puuid查询用户失败", zap.Error(err))
+		return nil, err
 	}
 	return data, nil
 }
 
+// 根据Riot ID(name#tag)查询用户信息,优先使用别名查询接口,旧客户端回退到按名称查询
+func QuerySummonerByRiotID(ctx context.Context, gameName, tagLine string) (*Summoner, error) {
+	list, err := LookupSummonerAliases(ctx, gameName, tagLine)
+	if IsNotFound(err) {
+		summoner, err := QuerySummonerByName(ctx, FormatRiotID(gameName, tagLine))
+		if IsNotFound(err) {
+			return nil, ErrSummonerNotFound
+		}
+		return summoner, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	riotID := FormatRiotID(gameName, tagLine)
+	switch len(list) {
+	case 0:
+		return nil, ErrSummonerNotFound
+	case 1:
+		return &list[0], nil
+	}
+	return nil, &AmbiguousSummonerError{Query: riotID, Candidates: list}
+}
+
+// 别名查询,返回匹配 name#tag 的所有召唤师
+func LookupSummonerAliases(ctx context.Context, gameName, tagLine string) ([]Summoner, error) {
+	cli, err := getCli()
+	if err != nil {
+		return nil, err
+	}
+	body := []struct {
+		GameName string `json:"gameName"`
+		TagLine  string `json:"tagLine"`
+	}{
+		{GameName: gameName, TagLine: tagLine},
+	}
+	bts, err := cli.httpPost(ctx, "/lol-summoner/v1/summoners/aliases", body)
+	if err != nil {
+		logger.Info("别名查询用户失败", zap.Error(err))
+		return nil, err
+	}
+	list := make([]Summoner, 0, 1)
+	if err = json.Unmarshal(bts, &list); err != nil {
+		return nil, err
+	}
+	// 接口对不存在的别名会返回空对象
+	res := make([]Summoner, 0, len(list))
+	for _, summoner := range list {
+		if summoner.Puuid != "" {
+			res = append(res, summoner)
+		}
+	}
+	return res, nil
+}
+
+// SearchSummoner 搜索召唤师,支持 name#tag 以及旧版召唤师名称
+func SearchSummoner(ctx context.Context, input string) (*Summoner, error) {
+	gameName, tagLine := ParseRiotID(input)
+	if gameName == "" {
+		return nil, ErrSummonerNotFound
+	}
+	if tagLine != "" {
+		return QuerySummonerByRiotID(ctx, gameName, tagLine)
+	}
+	summoner, err := QuerySummonerByName(ctx, gameName)
+	if IsNotFound(err) || (err == nil && summoner.Puuid == "") {
+		return nil, ErrSummonerNotFound
+	}
+	return summoner, err
+}
+
+// ParseRiotID 拆分 name#tag,以最后一个#为分隔,没有#时tag为空
+func ParseRiotID(input string) (gameName, tagLine string) {
+	input = strings.TrimSpace(input)
+	idx := strings.LastIndex(input, "#")
+	if idx < 0 {
+		return input, ""
+	}
+	return strings.TrimSpace(input[:idx]), strings.TrimSpace(input[idx+1:])
+}
+
+// escapeQuery 转义查询参数,空格转义为%20
+func escapeQuery(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// FormatRiotID 拼接 name#tag, tag为空时只返回name
+func FormatRiotID(gameName, tagLine string) string {
+	if tagLine == "" {
+		return gameName
+	}
+	return gameName + "#" + tagLine
+}
+
+// RiotID 优先返回 name#tag,旧客户端没有gameName时返回displayName
+func (s Summoner) RiotID() string {
+	if s.GameName == "" {
+		return s.DisplayName
+	}
+	return FormatRiotID(s.GameName, s.TagLine)
+}
+
+// RiotID 优先返回 name#tag,旧客户端没有gameName时返回displayName
+func (s CurrSummoner) RiotID() string {
+	if s.GameName == "" {
+		return s.DisplayName
+	}
+	return FormatRiotID(s.GameName, s.TagLine)
+}
+
+// FindParticipant 返回玩家在对局中的数据,不在对局中时返回nil
+func (g *GameSummary) FindParticipant(puuid string) *Participant {
+	participantID := 0
+	for _, identity := range g.ParticipantIdentities {
+		if identity.Player.Puuid == puuid {
+			participantID = identity.ParticipantId
+		}
+	}
+	if participantID == 0 {
+		return nil
+	}
+	for i := range g.Participants {
+		if g.Participants[i].ParticipantId == participantID {
+			return &g.Participants[i]
+		}
+	}
+	return nil
+}
+
 // 接受对局
-func AcceptGame() error {
-	_, err := cli.httpPost("/lol-matchmaking/v1/ready-check/accept", nil)
+func AcceptGame(ctx context.Context) error {
+	cli, err := getCli()
+	if err != nil {
+		return err
+	}
+	_, err = cli.httpPost(ctx, "/lol-matchmaking/v1/ready-check/accept", nil)
 	return err
 }
 
 // 获取选人会话
-func GetChampSelectSession() (*ChampSelectSessionInfo, error) {
-	bts, err := cli.httpGet("/lol-champ-select/v1/session")
+func GetChampSelectSession(ctx context.Context) (*ChampSelectSessionInfo, error) {
+	cli, err := getCli()
 	if err != nil {
 		return nil, err
 	}
 	data := &ChampSelectSessionInfo{}
-	err = json.Unmarshal(bts, data)
+	err = cli.getJSON(ctx, "/lol-champ-select/v1/session", data)
+	if IsNotFound(err) {
+		return nil, ErrNotInChampSelect
+	}
 	if err != nil {
 		logger.Info("查询选人会话详情失败", zap.Error(err))
 		return nil, err
 	}
-	if data.CommonResp.ErrorCode != "" {
-		return nil, errors.New(fmt.Sprintf("查询选人会话详情失败 :%s", data.CommonResp.Message))
-	}
 	return data, nil
 }
 
-func ChampSelectPatchAction(championID, actionID int, patchType ChampSelectPatchType,
+func ChampSelectPatchAction(ctx context.Context, championID, actionID int, patchType ChampSelectPatchType,
 	completed bool) error {
+	cli, err := getCli()
+	if err != nil {
+		return err
+	}
 	body := struct {
 		Completed  bool                 `json:"completed"`
 		Type       ChampSelectPatchType `json:"type"`
@@ -959,46 +1123,38 @@ func ChampSelectPatchAction(championID, actionID int, patchType ChampSelectPatch
 		Type:       patchType,
 		ChampionID: championID,
 	}
-	bts, err := cli.httpPatch(fmt.Sprintf("/lol-champ-select/v1/session/actions/%d", actionID), body)
-	if err != nil {
-		return err
+	_, err = cli.httpPatch(ctx, fmt.Sprintf("/lol-champ-select/v1/session/actions/%d", actionID), body)
+	if IsNotFound(err) {
+		return ErrNotInChampSelect
 	}
-	data := &CommonResp{}
-	err = json.Unmarshal(bts, data)
 	if err != nil {
-		logger.Info("ChampSelectPatchAction详情失败", zap.Error(err))
+		logger.Info("ChampSelectPatchAction失败", zap.Error(err))
 		return err
 	}
-	if data.ErrorCode != "" {
-		return errors.New(fmt.Sprintf("ChampSelectPatchAction失败 :%s", data.Message))
-	}
 	return nil
 }
 
 // 选择英雄
-func PickChampion(championID, actionID int) error {
-	return ChampSelectPatchAction(championID, actionID, ChampSelectPatchTypePick, true)
+func PickChampion(ctx context.Context, championID, actionID int) error {
+	return ChampSelectPatchAction(ctx, championID, actionID, ChampSelectPatchTypePick, true)
 }
 
 // ban英雄
-func BanChampion(championID, actionID int) error {
-	return ChampSelectPatchAction(championID, actionID, ChampSelectPatchTypeBan, true)
+func BanChampion(ctx context.Context, championID, actionID int) error {
+	return ChampSelectPatchAction(ctx, championID, actionID, ChampSelectPatchTypeBan, true)
 }
 
 // 查询游戏会话
-func QueryGameFlowSession() (*GameFlowSession, error) {
-	bts, err := cli.httpGet("/lol-gameflow/v1/session")
+func QueryGameFlowSession(ctx context.Context) (*GameFlowSession, error) {
+	cli, err := getCli()
 	if err != nil {
 		return nil, err
 	}
 	data := &GameFlowSession{}
-	err = json.Unmarshal(bts, data)
+	err = cli.getJSON(ctx, "/lol-gameflow/v1/session", data)
 	if err != nil {
 		logger.Info("查询游戏会话失败", zap.Error(err))
 		return nil, err
 	}
-	if data.CommonResp.ErrorCode != "" {
-		return nil, errors.New(fmt.Sprintf("查询游戏会话失败 :%s", data.CommonResp.Message))
-	}
 	return data, nil
 }
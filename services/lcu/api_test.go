@@ -0,0 +1,71 @@
+package lcu_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/lcutest"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+func TestParseRiotID(t *testing.T) {
+	cases := []struct {
+		input, name, tag string
+	}{
+		{"Faker#KR1", "Faker", "KR1"},
+		{"  我是 谁 # 123 ", "我是 谁", "123"},
+		{"a#b#c", "a#b", "c"},
+		{"legacy name", "legacy name", ""},
+	}
+	for _, c := range cases {
+		name, tag := lcu.ParseRiotID(c.input)
+		if name != c.name || tag != c.tag {
+			t.Errorf("ParseRiotID(%q) = %q, %q", c.input, name, tag)
+		}
+	}
+}
+
+func TestSearchSummoner(t *testing.T) {
+	srv := lcutest.NewServer()
+	defer srv.Close()
+	lcu.InitCli(srv.Port(), srv.Token)
+	ctx := context.Background()
+
+	spaced := lcutest.NewPlayer(1, models.TeamIDBlue)
+	spaced.Name = "a b&c"
+	twin1 := lcutest.NewPlayer(2, models.TeamIDBlue)
+	twin1.Name = "twin"
+	twin2 := lcutest.NewPlayer(3, models.TeamIDBlue)
+	twin2.Name = "twin"
+	srv.AddSummoners(spaced, twin1, twin2)
+
+	summoner, err := lcu.SearchSummoner(ctx, " a b&c#test ")
+	if err != nil || summoner.Puuid != spaced.Puuid {
+		t.Fatalf("search by riot id: %+v, %v", summoner, err)
+	}
+	summoner, err = lcu.SearchSummoner(ctx, "a b&c")
+	if err != nil || summoner.Puuid != spaced.Puuid {
+		t.Fatalf("search by legacy name: %+v, %v", summoner, err)
+	}
+	req := srv.Requests(http.MethodGet, "/lol-summoner/v1/summoners")
+	if len(req) != 1 || req[0].Query.Get("name") != "a b&c" {
+		t.Errorf("name not escaped: %+v", req)
+	}
+
+	_, err = lcu.SearchSummoner(ctx, "twin#test")
+	var ambiguousErr *lcu.AmbiguousSummonerError
+	if !errors.As(err, &ambiguousErr) || len(ambiguousErr.Candidates) != 2 ||
+		!strings.Contains(err.Error(), "twin#test") {
+		t.Errorf("expect ambiguous error, got %v", err)
+	}
+	if _, err = lcu.SearchSummoner(ctx, "nobody#test"); err != lcu.ErrSummonerNotFound {
+		t.Errorf("expect not found, got %v", err)
+	}
+	if _, err = lcu.SearchSummoner(ctx, "nobody"); err != lcu.ErrSummonerNotFound {
+		t.Errorf("expect not found, got %v", err)
+	}
+}
@@ -2,9 +2,9 @@ package lcu
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,8 +23,7 @@ var (
 			},
 		},
 	}
-	errLolClientNotFound = errors.New("未找到lol进程")
-	cli                  *client
+	cli *client
 )
 
 type (
@@ -32,6 +31,7 @@ type (
 		port    int
 		authPwd string
 		baseUrl string
+		retry   RetryPolicy
 	}
 )
 
@@ -45,39 +45,99 @@ func NewClient(port int, token string) *client {
 	cli := &client{
 		port:    port,
 		authPwd: token,
+		retry:   DefaultRetryPolicy,
 	}
 	cli.baseUrl = cli.fmtClientApiUrl()
 	return cli
 }
-func (cli client) httpGet(url string) ([]byte, error) {
-	return cli.req(http.MethodGet, url, nil)
+
+// getCli 获取当前客户端,未连接时返回 ErrClientGone
+func getCli() (*client, error) {
+	if cli == nil {
+		return nil, ErrClientGone
+	}
+	return cli, nil
+}
+
+func (cli client) httpGet(ctx context.Context, url string) ([]byte, error) {
+	return cli.req(ctx, http.MethodGet, url, nil, cli.retry)
 }
-func (cli client) httpPost(url string, body interface{}) ([]byte, error) {
-	return cli.req(http.MethodPost, url, body)
+func (cli client) httpPost(ctx context.Context, url string, body interface{}) ([]byte, error) {
+	return cli.req(ctx, http.MethodPost, url, body, NoRetryPolicy)
 }
-func (cli client) httpPatch(url string, body interface{}) ([]byte, error) {
-	return cli.req(http.MethodPatch, url, body)
+func (cli client) httpPatch(ctx context.Context, url string, body interface{}) ([]byte, error) {
+	return cli.req(ctx, http.MethodPatch, url, body, NoRetryPolicy)
 }
-func (cli client) httpDel(url string) ([]byte, error) {
-	return cli.req(http.MethodDelete, url, nil)
+func (cli client) httpDel(ctx context.Context, url string) ([]byte, error) {
+	return cli.req(ctx, http.MethodDelete, url, nil, cli.retry)
 }
-func (cli client) req(method string, url string, data interface{}) ([]byte, error) {
-	var body io.Reader
+
+// getJSON GET请求并解析json到v
+func (cli client) getJSON(ctx context.Context, url string, v interface{}) error {
+	return cli.getJSONWithPolicy(ctx, url, v, cli.retry)
+}
+
+// getJSONWithPolicy 按指定重试策略GET请求并解析json到v
+func (cli client) getJSONWithPolicy(ctx context.Context, url string, v interface{}, policy RetryPolicy) error {
+	bts, err := cli.req(ctx, http.MethodGet, url, nil, policy)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bts, v)
+}
+
+func (cli client) req(ctx context.Context, method string, url string, data interface{},
+	policy RetryPolicy) ([]byte, error) {
+	var bts []byte
 	if data != nil {
-		bts, err := json.Marshal(data)
+		var err error
+		bts, err = json.Marshal(data)
 		if err != nil {
 			return nil, err
 		}
+	}
+	var respBody []byte
+	err := policy.do(ctx, func() error {
+		if policy.BeforeAttempt != nil {
+			if err := policy.BeforeAttempt(ctx); err != nil {
+				return err
+			}
+		}
+		var err error
+		respBody, err = cli.doReq(ctx, method, url, bts)
+		return err
+	})
+	return respBody, err
+}
+
+func (cli client) doReq(ctx context.Context, method string, url string, bts []byte) ([]byte, error) {
+	var body io.Reader
+	if bts != nil {
 		body = bytes.NewReader(bts)
 	}
-	req, _ := http.NewRequest(method, cli.baseUrl+url, body)
+	req, err := http.NewRequestWithContext(ctx, method, cli.baseUrl+url, body)
+	if err != nil {
+		return nil, err
+	}
 	if req.Body != nil {
-		req.Header.Add("ContentType", "application/json")
+		req.Header.Add("Content-Type", "application/json")
 	}
 	resp, err := httpCli.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		apiErr := &APIError{}
+		_ = json.Unmarshal(respBody, apiErr)
+		apiErr.Method = method
+		apiErr.Path = url
+		apiErr.HttpStatus = resp.StatusCode
+		return nil, apiErr
+	}
+	return respBody, nil
 }
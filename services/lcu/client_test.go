@@ -0,0 +1,39 @@
+package lcu
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestQueryGameSummaryLimitEachAttempt(t *testing.T) {
+	var requests int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	port, _ := strconv.Atoi(srv.URL[strings.LastIndex(srv.URL, ":")+1:])
+	InitCli(port, "token")
+	defer func() { cli = nil }()
+
+	// 限流只允许2次请求,第3次尝试等待限流超时
+	limiter := queryGameSummaryLimiter
+	queryGameSummaryLimiter = rate.NewLimiter(rate.Every(time.Hour), 2)
+	defer func() { queryGameSummaryLimiter = limiter }()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := QueryGameSummary(ctx, 1); err == nil {
+		t.Fatal("503 应返回错误")
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("重试也应等待限流, 请求 %d 次", n)
+	}
+}
@@ -1,7 +1,6 @@
 package lcu
 
 import (
-	"github.com/beastars1/lol-prophet-gui/pkg/windows/process"
 	"regexp"
 	"strconv"
 
@@ -14,25 +13,30 @@ const (
 
 var (
 	lolCommandlineReg     = regexp.MustCompile(`--remoting-auth-token=(.+?)" "--app-port=(\d+)"`)
+	lolAuthTokenArgReg    = regexp.MustCompile(`--remoting-auth-token=([^"\s\x00]+)`)
+	lolAppPortArgReg      = regexp.MustCompile(`--app-port=(\d+)`)
 	ErrLolProcessNotFound = errors.New("未找到lol进程")
 )
 
 // GetLolClientApiInfo 获取lcu认证信息
 func GetLolClientApiInfo() (int, string, error) {
-	return GetLolClientApiInfoV3()
+	return DefaultDiscoverers().Discover()
 }
 
-func GetLolClientApiInfoV3() (port int, token string, err error) {
-	cmdline, err := process.GetProcessCommand(lolUxProcessName)
-	if err != nil {
-		err = ErrLolProcessNotFound
+// 从命令行中解析出端口和token,兼容windows带引号的格式和/proc中以\0分隔的格式
+func parseClientCommandline(cmdline string) (port int, token string, err error) {
+	btsChunk := lolCommandlineReg.FindSubmatch([]byte(cmdline))
+	if len(btsChunk) >= 3 {
+		token = string(btsChunk[1])
+		port, err = strconv.Atoi(string(btsChunk[2]))
 		return
 	}
-	btsChunk := lolCommandlineReg.FindSubmatch([]byte(cmdline))
-	if len(btsChunk) < 3 {
+	tokenChunk := lolAuthTokenArgReg.FindStringSubmatch(cmdline)
+	portChunk := lolAppPortArgReg.FindStringSubmatch(cmdline)
+	if len(tokenChunk) < 2 || len(portChunk) < 2 {
 		return port, token, ErrLolProcessNotFound
 	}
-	token = string(btsChunk[1])
-	port, err = strconv.Atoi(string(btsChunk[2]))
+	token = tokenChunk[1]
+	port, err = strconv.Atoi(portChunk[1])
 	return
 }
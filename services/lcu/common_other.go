@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package lcu
+
+// GetLolClientApiInfoV3 非windows系统无法读取进程命令行,交给 proc/lockfile 方式处理
+func GetLolClientApiInfoV3() (port int, token string, err error) {
+	return port, token, ErrLolProcessNotFound
+}
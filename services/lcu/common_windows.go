@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package lcu
+
+import (
+	"github.com/beastars1/lol-prophet-gui/pkg/windows/process"
+)
+
+func GetLolClientApiInfoV3() (port int, token string, err error) {
+	cmdline, err := process.GetProcessCommand(lolUxProcessName)
+	if err != nil {
+		err = ErrLolProcessNotFound
+		return
+	}
+	return parseClientCommandline(cmdline)
+}
@@ -0,0 +1,256 @@
+package lcu
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/pkg/errors"
+)
+
+// 客户端发现方式
+const (
+	DiscoverTypeEnv      = "env"      // 配置或环境变量中指定的端口和token
+	DiscoverTypeProcess  = "process"  // 读取windows进程命令行
+	DiscoverTypeProc     = "proc"     // 读取 /proc/<pid>/cmdline (wine/proton)
+	DiscoverTypeLockfile = "lockfile" // 读取客户端安装目录下的 lockfile
+)
+
+const (
+	defaultProcRoot = "/proc"
+)
+
+var (
+	errBadLockfile = errors.New("lockfile格式错误")
+	errBadEnvConf  = errors.New("lcuPort和lcuToken需要同时配置且端口大于0")
+	// DefaultDiscoverOrder 默认的客户端发现顺序
+	DefaultDiscoverOrder = []string{DiscoverTypeEnv, DiscoverTypeProcess, DiscoverTypeProc, DiscoverTypeLockfile}
+	defaultLockfilePaths = []string{
+		`C:\Riot Games\League of Legends\lockfile`,
+		`D:\Riot Games\League of Legends\lockfile`,
+	}
+	defaultWineLockfilePaths = []string{
+		"Games/league-of-legends/drive_c/Riot Games/League of Legends/lockfile",
+		".wine/drive_c/Riot Games/League of Legends/lockfile",
+	}
+)
+
+type (
+	// Discoverer 获取lcu端口和token的一种方式
+	Discoverer interface {
+		Name() string
+		Discover() (port int, token string, err error)
+	}
+	// Discoverers 按顺序尝试的发现方式列表
+	Discoverers []Discoverer
+
+	envDiscoverer struct {
+		port  int
+		token string
+	}
+	processDiscoverer struct{}
+	procDiscoverer    struct {
+		root string
+	}
+	lockfileDiscoverer struct {
+		paths []string
+	}
+	// DiscoverError 所有发现方式都失败,且至少一种方式出错而不只是没找到客户端
+	DiscoverError struct {
+		Errs []error // 各发现方式的错误,已带上发现方式名称
+	}
+)
+
+func (e *DiscoverError) Error() string {
+	msgs := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return "查找lol客户端失败: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap 返回第一个错误
+func (e *DiscoverError) Unwrap() error {
+	return e.Errs[0]
+}
+
+// DefaultDiscoverers 不依赖配置的默认发现方式
+func DefaultDiscoverers() Discoverers {
+	return NewDiscoverers(&conf.LcuConf{})
+}
+
+// NewDiscoverers 根据配置的顺序创建发现方式,未知的类型会被忽略
+func NewDiscoverers(cfg *conf.LcuConf) Discoverers {
+	order := cfg.Discovery
+	if len(order) == 0 {
+		order = DefaultDiscoverOrder
+	}
+	list := make(Discoverers, 0, len(order))
+	for _, name := range order {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case DiscoverTypeEnv:
+			list = append(list, NewEnvDiscoverer(cfg.Port, cfg.Token))
+		case DiscoverTypeProcess:
+			list = append(list, NewProcessDiscoverer())
+		case DiscoverTypeProc:
+			list = append(list, NewProcDiscoverer(defaultProcRoot))
+		case DiscoverTypeLockfile:
+			paths := defaultLockfilePathList()
+			if cfg.LockfilePath != "" {
+				paths = append([]string{cfg.LockfilePath}, paths...)
+			}
+			list = append(list, NewLockfileDiscoverer(paths...))
+		}
+	}
+	return list
+}
+
+// Discover 依次尝试每种方式,返回第一个成功的结果
+func (list Discoverers) Discover() (port int, token string, err error) {
+	port, token, _, err = list.DiscoverWithName()
+	return
+}
+
+// DiscoverWithName 同 Discover,额外返回成功的发现方式名称
+// 全部只是没找到客户端时返回 ErrLolProcessNotFound,有其他错误时返回 *DiscoverError
+func (list Discoverers) DiscoverWithName() (port int, token string, name string, err error) {
+	var errs []error
+	for _, d := range list {
+		port, token, err = d.Discover()
+		if err == nil && port > 0 && token != "" {
+			return port, token, d.Name(), nil
+		}
+		if err != nil && !errors.Is(err, ErrLolProcessNotFound) {
+			errs = append(errs, errors.Wrap(err, d.Name()))
+		}
+	}
+	if len(errs) > 0 {
+		return 0, "", "", &DiscoverError{Errs: errs}
+	}
+	return 0, "", "", ErrLolProcessNotFound
+}
+
+func NewEnvDiscoverer(port int, token string) Discoverer {
+	return envDiscoverer{port: port, token: token}
+}
+
+func (d envDiscoverer) Name() string {
+	return DiscoverTypeEnv
+}
+
+func (d envDiscoverer) Discover() (int, string, error) {
+	if d.port == 0 && d.token == "" {
+		return 0, "", ErrLolProcessNotFound
+	}
+	if d.port <= 0 || d.token == "" {
+		return 0, "", errBadEnvConf
+	}
+	return d.port, d.token, nil
+}
+
+func NewProcessDiscoverer() Discoverer {
+	return processDiscoverer{}
+}
+
+func (d processDiscoverer) Name() string {
+	return DiscoverTypeProcess
+}
+
+func (d processDiscoverer) Discover() (int, string, error) {
+	return GetLolClientApiInfoV3()
+}
+
+func NewProcDiscoverer(root string) Discoverer {
+	return procDiscoverer{root: root}
+}
+
+func (d procDiscoverer) Name() string {
+	return DiscoverTypeProc
+}
+
+func (d procDiscoverer) Discover() (int, string, error) {
+	entries, err := os.ReadDir(d.root)
+	if err != nil {
+		return 0, "", ErrLolProcessNotFound
+	}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+		bts, err := os.ReadFile(filepath.Join(d.root, entry.Name(), "cmdline"))
+		if err != nil || len(bts) == 0 {
+			continue
+		}
+		cmdline := strings.ReplaceAll(string(bts), "\x00", " ")
+		if !strings.Contains(cmdline, lolUxProcessName) {
+			continue
+		}
+		port, token, err := parseClientCommandline(cmdline)
+		if err != nil {
+			continue
+		}
+		return port, token, nil
+	}
+	return 0, "", ErrLolProcessNotFound
+}
+
+func NewLockfileDiscoverer(paths ...string) Discoverer {
+	return lockfileDiscoverer{paths: paths}
+}
+
+func (d lockfileDiscoverer) Name() string {
+	return DiscoverTypeLockfile
+}
+
+func (d lockfileDiscoverer) Discover() (int, string, error) {
+	// 没有权限等读取错误,其他路径都没找到时返回
+	var readErr error
+	for _, path := range d.paths {
+		bts, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) && readErr == nil {
+				readErr = err
+			}
+			continue
+		}
+		port, token, err := ParseLockfile(string(bts))
+		if err != nil {
+			return 0, "", errors.Wrap(err, path)
+		}
+		return port, token, nil
+	}
+	if readErr != nil {
+		return 0, "", readErr
+	}
+	return 0, "", ErrLolProcessNotFound
+}
+
+// ParseLockfile 解析lockfile内容 格式: LeagueClient:pid:port:token:protocol
+func ParseLockfile(content string) (port int, token string, err error) {
+	parts := strings.Split(strings.TrimSpace(content), ":")
+	if len(parts) != 5 {
+		return 0, "", errBadLockfile
+	}
+	port, err = strconv.Atoi(parts[2])
+	if err != nil || port <= 0 {
+		return 0, "", errBadLockfile
+	}
+	token = parts[3]
+	if token == "" {
+		return 0, "", errBadLockfile
+	}
+	return port, token, nil
+}
+
+func defaultLockfilePathList() []string {
+	paths := make([]string, 0, len(defaultLockfilePaths)+len(defaultWineLockfilePaths))
+	paths = append(paths, defaultLockfilePaths...)
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, p := range defaultWineLockfilePaths {
+			paths = append(paths, filepath.Join(home, p))
+		}
+	}
+	return paths
+}
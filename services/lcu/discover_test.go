@@ -0,0 +1,93 @@
+package lcu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLockfileDiscoverer(t *testing.T) {
+	dir := t.TempDir()
+	lockfile := filepath.Join(dir, "lockfile")
+	err := os.WriteFile(lockfile, []byte("LeagueClient:12345:54321:abc-TOKEN_1:https"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewLockfileDiscoverer(filepath.Join(dir, "not-exist"), lockfile)
+	port, token, err := d.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 54321 || token != "abc-TOKEN_1" {
+		t.Errorf("got port %d token %s", port, token)
+	}
+
+	_ = os.WriteFile(lockfile, []byte("LeagueClient:12345"), 0o644)
+	if _, _, err = d.Discover(); err == nil {
+		t.Error("expected error for bad lockfile")
+	}
+	if _, _, err = NewLockfileDiscoverer(filepath.Join(dir, "not-exist")).Discover(); err != ErrLolProcessNotFound {
+		t.Errorf("expected ErrLolProcessNotFound, got %v", err)
+	}
+}
+
+func TestProcDiscoverer(t *testing.T) {
+	root := t.TempDir()
+	writeCmdline := func(pid, cmdline string) {
+		_ = os.MkdirAll(filepath.Join(root, pid), 0o755)
+		_ = os.WriteFile(filepath.Join(root, pid, "cmdline"), []byte(cmdline), 0o644)
+	}
+	writeCmdline("12", "/usr/bin/bash\x00")
+	writeCmdline("self", "LeagueClientUx.exe\x00")
+	writeCmdline("345", `C:\Riot Games\League of Legends\LeagueClientUx.exe`+"\x00--riotclient-auth-token=xx\x00"+
+		"--remoting-auth-token=procToken\x00--app-port=2999\x00")
+
+	port, token, err := NewProcDiscoverer(root).Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 2999 || token != "procToken" {
+		t.Errorf("got port %d token %s", port, token)
+	}
+}
+
+func TestDiscoverersOrder(t *testing.T) {
+	list := Discoverers{
+		NewEnvDiscoverer(0, ""),
+		NewEnvDiscoverer(1234, "envToken"),
+	}
+	port, token, name, err := list.DiscoverWithName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 1234 || token != "envToken" || name != DiscoverTypeEnv {
+		t.Errorf("got port %d token %s name %s", port, token, name)
+	}
+	if _, _, err = (Discoverers{}).Discover(); err != ErrLolProcessNotFound {
+		t.Errorf("expected ErrLolProcessNotFound, got %v", err)
+	}
+	// 配置错误和读取失败不应被当作没找到客户端
+	dir := t.TempDir()
+	list = Discoverers{NewEnvDiscoverer(1234, ""), NewLockfileDiscoverer(filepath.Join(dir, "not-exist"), dir)}
+	_, _, err = list.Discover()
+	discoverErr, ok := err.(*DiscoverError)
+	if !ok || len(discoverErr.Errs) != 2 || errors.Is(err, ErrLolProcessNotFound) {
+		t.Fatalf("expected DiscoverError, got %v", err)
+	}
+	if !errors.Is(err, errBadEnvConf) {
+		t.Errorf("expected errBadEnvConf, got %v", err)
+	}
+}
+
+func TestParseClientCommandline(t *testing.T) {
+	cmdline := `"C:/Riot Games/League of Legends/LeagueClientUx.exe" "--remoting-auth-token=winToken" "--app-port=50123" "--install-directory=C:/Riot Games"`
+	port, token, err := parseClientCommandline(cmdline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 50123 || token != "winToken" {
+		t.Errorf("got port %d token %s", port, token)
+	}
+}
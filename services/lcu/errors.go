@@ -0,0 +1,135 @@
+package lcu
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrClientGone lcu客户端未连接或已关闭
+	ErrClientGone = errors.New("lol客户端未连接")
+	// ErrNotInChampSelect 当前不在英雄选择阶段
+	ErrNotInChampSelect = errors.New("当前不在英雄选择阶段")
+	// ErrSummonerNotFound 搜索不到召唤师
+	ErrSummonerNotFound = errors.New("未查询到召唤师")
+)
+
+type (
+	// APIError lcu接口返回的非2xx响应
+	APIError struct {
+		Method     string `json:"-"`
+		Path       string `json:"-"`
+		HttpStatus int    `json:"httpStatus"`
+		ErrorCode  string `json:"errorCode"`
+		Message    string `json:"message"`
+	}
+	// AmbiguousSummonerError 搜索结果不唯一
+	AmbiguousSummonerError struct {
+		Query      string
+		Candidates []Summoner
+	}
+	// RetryPolicy 请求失败时的重试策略,只重试临时性错误
+	RetryPolicy struct {
+		Attempts uint
+		Delay    time.Duration
+		MaxDelay time.Duration
+		// BeforeAttempt 每次请求(包括重试)前调用,如等待限流,返回错误时不再重试
+		BeforeAttempt func(ctx context.Context) error
+	}
+)
+
+var (
+	// DefaultRetryPolicy 默认重试策略
+	DefaultRetryPolicy = RetryPolicy{
+		Attempts: 3,
+		Delay:    time.Millisecond * 100,
+		MaxDelay: time.Second,
+	}
+	// NoRetryPolicy 不重试,用于非幂等操作
+	NoRetryPolicy = RetryPolicy{Attempts: 1}
+)
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.HttpStatus)
+	}
+	return fmt.Sprintf("lcu %s %s %d %s: %s", e.Method, e.Path, e.HttpStatus, e.ErrorCode, msg)
+}
+
+func (e *AmbiguousSummonerError) Error() string {
+	names := make([]string, 0, len(e.Candidates))
+	for _, summoner := range e.Candidates {
+		names = append(names, summoner.RiotID())
+	}
+	return fmt.Sprintf("找到多个名为 %s 的召唤师: %s", e.Query, strings.Join(names, ", "))
+}
+
+// Temporary 限流和服务端错误可以重试
+func (e *APIError) Temporary() bool {
+	return e.HttpStatus == http.StatusTooManyRequests || e.HttpStatus >= http.StatusInternalServerError
+}
+
+// AsAPIError 取出错误链中的 *APIError
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// IsNotFound 资源不存在,例如不在选人阶段时查询选人会话
+func IsNotFound(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.HttpStatus == http.StatusNotFound
+}
+
+// IsRateLimited 请求被客户端限流
+func IsRateLimited(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.HttpStatus == http.StatusTooManyRequests
+}
+
+// IsClientGone 客户端未启动、已关闭或认证信息失效
+func IsClientGone(err error) bool {
+	if errors.Is(err, ErrClientGone) {
+		return true
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.HttpStatus == http.StatusUnauthorized
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func isRetryable(err error) bool {
+	if err == nil || IsClientGone(err) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	if p.Attempts <= 1 {
+		return fn()
+	}
+	return retry.Do(fn,
+		retry.Context(ctx),
+		retry.Attempts(p.Attempts),
+		retry.Delay(p.Delay),
+		retry.MaxDelay(p.MaxDelay),
+		retry.RetryIf(isRetryable),
+		retry.LastErrorOnly(true),
+	)
+}
@@ -0,0 +1,56 @@
+package lcu_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/lcutest"
+)
+
+func TestAPIErrorTyping(t *testing.T) {
+	srv := lcutest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	lcu.InitCli(srv.Port(), srv.Token)
+	_, err := lcu.GetChampSelectSession(ctx)
+	if err != lcu.ErrNotInChampSelect {
+		t.Errorf("404 选人会话应返回 ErrNotInChampSelect, got %v", err)
+	}
+	_, err = lcu.QueryGameFlowSession(ctx)
+	apiErr, ok := lcu.AsAPIError(err)
+	if !ok || apiErr.HttpStatus != http.StatusNotFound || apiErr.ErrorCode != "RESOURCE_NOT_FOUND" ||
+		apiErr.Method != http.MethodGet || apiErr.Path != "/lol-gameflow/v1/session" {
+		t.Errorf("bad api error %#v", err)
+	}
+	if !lcu.IsNotFound(err) || lcu.IsClientGone(err) {
+		t.Errorf("404 不应视为客户端断开: %v", err)
+	}
+
+	srv.Handle(http.MethodGet, "/lol-gameflow/v1/session", http.StatusServiceUnavailable, nil)
+	_, err = lcu.QueryGameFlowSession(ctx)
+	if apiErr, ok = lcu.AsAPIError(err); !ok || !apiErr.Temporary() {
+		t.Errorf("503 应为临时错误: %v", err)
+	}
+	if n := len(srv.Requests(http.MethodGet, "/lol-gameflow/v1/session")); n != 1+int(lcu.DefaultRetryPolicy.Attempts) {
+		t.Errorf("503 应重试 %d 次, 实际请求 %d 次", lcu.DefaultRetryPolicy.Attempts, n-1)
+	}
+
+	lcu.InitCli(srv.Port(), "bad-token")
+	_, err = lcu.QueryGameFlowSession(ctx)
+	if !lcu.IsClientGone(err) {
+		t.Errorf("401 应视为客户端断开: %v", err)
+	}
+	if n := len(srv.Requests(http.MethodGet, "/lol-gameflow/v1/session")); n != 1+int(lcu.DefaultRetryPolicy.Attempts) {
+		t.Errorf("401 不应重试, 请求数 %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	lcu.InitCli(srv.Port(), srv.Token)
+	if _, err = lcu.QueryGameFlowSession(ctx); err == nil {
+		t.Error("ctx 取消后请求应失败")
+	}
+}
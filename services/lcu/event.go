@@ -0,0 +1,182 @@
+package lcu
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	WampOpcode int    // wamp消息类型
+	EventType  string // lcu事件类型
+	// WampMessage lcu websocket 的原始消息 [opcode, topic, payload]
+	WampMessage struct {
+		Opcode  WampOpcode
+		Topic   string
+		Payload json.RawMessage
+	}
+	// Event OnJsonApiEvent 推送的事件
+	Event struct {
+		Data      json.RawMessage `json:"data"`
+		EventType EventType       `json:"eventType"`
+		Uri       string          `json:"uri"`
+	}
+	EventHandler func(evt *Event)
+	// EventBus 按uri分发lcu事件,支持通配符
+	EventBus struct {
+		mu     sync.RWMutex
+		nextID int
+		subs   []eventSubscription
+	}
+	eventSubscription struct {
+		id      int
+		pattern string
+		types   []EventType
+		handler EventHandler
+	}
+)
+
+// wamp opcode
+const (
+	WampOpcodeSubscribe   WampOpcode = 5
+	WampOpcodeUnsubscribe WampOpcode = 6
+	WampOpcodeEvent       WampOpcode = 8
+)
+
+const (
+	EventTypeCreate EventType = "Create"
+	EventTypeUpdate EventType = "Update"
+	EventTypeDelete EventType = "Delete"
+)
+
+const (
+	OnJsonApiEventTopic = "OnJsonApiEvent"
+)
+
+var (
+	errBadWampMessage = errors.New("错误的wamp消息")
+)
+
+// EncodeWampMessage 生成订阅/取消订阅消息
+func EncodeWampMessage(opcode WampOpcode, topic string) []byte {
+	bts, _ := json.Marshal([]interface{}{opcode, topic})
+	return bts
+}
+
+// DecodeWampMessage 解析wamp消息
+func DecodeWampMessage(message []byte) (*WampMessage, error) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(message, &parts); err != nil {
+		return nil, errors.Wrap(errBadWampMessage, err.Error())
+	}
+	if len(parts) < 2 {
+		return nil, errBadWampMessage
+	}
+	msg := &WampMessage{}
+	if err := json.Unmarshal(parts[0], &msg.Opcode); err != nil {
+		return nil, errors.Wrap(errBadWampMessage, err.Error())
+	}
+	if err := json.Unmarshal(parts[1], &msg.Topic); err != nil {
+		return nil, errors.Wrap(errBadWampMessage, err.Error())
+	}
+	if len(parts) > 2 {
+		msg.Payload = parts[2]
+	}
+	return msg, nil
+}
+
+// DecodeEvent 解析 OnJsonApiEvent 事件消息
+func DecodeEvent(message []byte) (*Event, error) {
+	msg, err := DecodeWampMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Opcode != WampOpcodeEvent || msg.Topic != OnJsonApiEventTopic || len(msg.Payload) == 0 {
+		return nil, errBadWampMessage
+	}
+	evt := &Event{}
+	if err = json.Unmarshal(msg.Payload, evt); err != nil {
+		return nil, errors.Wrap(errBadWampMessage, err.Error())
+	}
+	return evt, nil
+}
+
+// Decode 将事件数据解析到v
+func (evt *Event) Decode(v interface{}) error {
+	return json.Unmarshal(evt.Data, v)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe 订阅uri, pattern 支持 path.Match 通配符以及以*结尾的前缀匹配,
+// types 为空时接收所有事件类型,返回取消订阅函数
+func (b *EventBus) Subscribe(pattern string, handler EventHandler, types ...EventType) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, eventSubscription{
+		id:      id,
+		pattern: pattern,
+		types:   types,
+		handler: handler,
+	})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.subs {
+			if sub.id == id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish 将事件同步分发给所有匹配的订阅者
+func (b *EventBus) Publish(evt *Event) {
+	b.mu.RLock()
+	handlers := make([]EventHandler, 0, 2)
+	for _, sub := range b.subs {
+		if sub.match(evt) {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(evt)
+	}
+}
+
+func (sub eventSubscription) match(evt *Event) bool {
+	if len(sub.types) > 0 {
+		typeMatched := false
+		for _, t := range sub.types {
+			if t == evt.EventType {
+				typeMatched = true
+				break
+			}
+		}
+		if !typeMatched {
+			return false
+		}
+	}
+	return MatchUri(sub.pattern, evt.Uri)
+}
+
+// MatchUri 判断uri是否匹配pattern
+func MatchUri(pattern, uri string) bool {
+	if pattern == "*" || pattern == uri {
+		return true
+	}
+	if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern && !strings.ContainsAny(prefix, "*?[") {
+		return strings.HasPrefix(uri, prefix)
+	}
+	ok, _ := path.Match(pattern, uri)
+	return ok
+}
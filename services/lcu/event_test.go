@@ -0,0 +1,48 @@
+package lcu
+
+import (
+	"testing"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	evt, err := DecodeEvent([]byte(`[8,"OnJsonApiEvent",{"data":"ChampSelect","eventType":"Update","uri":"/lol-gameflow/v1/gameflow-phase"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Uri != "/lol-gameflow/v1/gameflow-phase" || evt.EventType != EventTypeUpdate {
+		t.Errorf("bad event %+v", evt)
+	}
+	var phase string
+	if err = evt.Decode(&phase); err != nil || phase != "ChampSelect" {
+		t.Errorf("decode data got %s, %v", phase, err)
+	}
+	if _, err = DecodeEvent([]byte(`[5,"OnJsonApiEvent"]`)); err == nil {
+		t.Error("subscribe message should not decode as event")
+	}
+	if _, err = DecodeEvent([]byte(`not json`)); err == nil {
+		t.Error("expected error for bad message")
+	}
+	if string(EncodeWampMessage(WampOpcodeSubscribe, OnJsonApiEventTopic)) != `[5,"OnJsonApiEvent"]` {
+		t.Error("bad subscribe message")
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	var exact, wildcard, prefix, deleted int
+	bus.Subscribe("/lol-lobby/v2/lobby", func(evt *Event) { exact++ })
+	bus.Subscribe("/lol-lobby/v2/*", func(evt *Event) { wildcard++ })
+	bus.Subscribe("/lol-lobby/?2/lobby", func(evt *Event) { exact++ })
+	unsubscribe := bus.Subscribe("/lol-end-of-game*", func(evt *Event) { prefix++ })
+	bus.Subscribe("*", func(evt *Event) { deleted++ }, EventTypeDelete)
+
+	bus.Publish(&Event{Uri: "/lol-lobby/v2/lobby", EventType: EventTypeUpdate})
+	bus.Publish(&Event{Uri: "/lol-lobby/v2/lobby/members", EventType: EventTypeCreate})
+	bus.Publish(&Event{Uri: "/lol-end-of-game/v1/eog-stats-block", EventType: EventTypeDelete})
+	unsubscribe()
+	bus.Publish(&Event{Uri: "/lol-end-of-game/v1/eog-stats-block", EventType: EventTypeUpdate})
+
+	if exact != 2 || wildcard != 2 || prefix != 1 || deleted != 1 {
+		t.Errorf("exact %d wildcard %d prefix %d deleted %d", exact, wildcard, prefix, deleted)
+	}
+}
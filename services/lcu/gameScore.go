@@ -2,45 +2,138 @@ package lcu
 
 import (
 	"fmt"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
 	"strings"
+	"time"
 )
 
 type (
 	UserScore struct {
-		SummonerID   int64    `json:"summonerID"`
-		SummonerName string   `json:"summonerName"`
-		Score        float64  `json:"score"`
-		CurrKDA      [][3]int `json:"currKDA"`
+		Puuid        string  `json:"puuid"`
+		SummonerID   int64   `json:"summonerID"`
+		SummonerName string  `json:"summonerName"`
+		Score        float64 `json:"score"`
+		// EffectiveGames 加权后的有效对局数, Confidence 根据有效对局数得出的可信度 0-1
+		EffectiveGames float64  `json:"effectiveGames"`
+		Confidence     float64  `json:"confidence"`
+		CurrKDA        [][3]int `json:"currKDA"`
+		// Mode 查询时所在的游戏模式,决定使用的马匹分数线
+		Mode string `json:"mode,omitempty"`
+		// ModeFallback 当前模式没有对局,使用了全部模式的对局,此时 Mode 为空,按全局马匹分数线判断
+		ModeFallback bool `json:"modeFallback,omitempty"`
+		// ModelScores 配置了自定义评分模型时各模型的得分
+		ModelScores map[string]float64 `json:"modelScores,omitempty"`
+		// Laning 按对局权重平均的对线得分,没有对线数据时为空
+		Laning *float64 `json:"laning,omitempty"`
+		// Badges 近期对局中反复出现的挂机/投降/送人头行为
+		Badges []Badge `json:"badges,omitempty"`
+		// Streak 连胜连败及近期胜率,未开启时为空
+		Streak *Streak `json:"streak,omitempty"`
+		// Account 疑似小号或代练/共享账号的可能性,未开启时为空
+		Account *AccountCheck `json:"account,omitempty"`
+		// Champions 近期各英雄的战绩,按对局数倒序
+		Champions []ChampionStat `json:"champions,omitempty"`
+		// Games 参与计算的每一局得分,按时间倒序
+		Games []GameScore `json:"games"`
+	}
+	// GameScore 一局的得分明细
+	GameScore struct {
+		GameID       int64              `json:"gameID"`
+		ChampionID   int                `json:"championID"`
+		QueueID      models.GameQueueID `json:"queueID"`
+		Position     models.Position    `json:"position"`
+		GameCreation time.Time          `json:"gameCreation"`
+		KDA          [3]int             `json:"kda"`
+		Win          bool               `json:"win"`
+		Score        float64            `json:"score"`
+		Weight       float64            `json:"weight"`           // 计算总分时的权重,所有对局合计为1
+		Laning       *float64           `json:"laning,omitempty"` // 对线得分,0为均势
+		Behaviors    []Behavior         `json:"behaviors,omitempty"`
+		Reasons      []IncScoreReason   `json:"reasons"`
+	}
+	// Streak 根据全部近期战绩统计的连胜连败
+	Streak struct {
+		Wins         int  `json:"wins"`   // 当前连胜局数
+		Losses       int  `json:"losses"` // 当前连败局数
+		RecentGames  int  `json:"recentGames"`
+		RecentWins   int  `json:"recentWins"`
+		SessionGames int  `json:"sessionGames"` // 本次游戏已经玩了多少局
+		Tilt         bool `json:"tilt"`         // 近期输得太多,可能上头
+	}
+	// AccountCheck 小号及代练/共享账号的可能性 0-1,以及命中的信号
+	AccountCheck struct {
+		Smurf          float64  `json:"smurf"`
+		Boosted        float64  `json:"boosted"`
+		SmurfReasons   []string `json:"smurfReasons"`
+		BoostedReasons []string `json:"boostedReasons"`
+	}
+	// ChampionStat 玩家一个英雄的近期战绩,Games/Wins/KDA 统计全部近期战绩,Score 只统计当前模式参与计算的对局
+	ChampionStat struct {
+		ChampionID  int        `json:"championID"`
+		Games       int        `json:"games"`
+		Wins        int        `json:"wins"`
+		KDA         [3]float64 `json:"kda"` // 场均击杀/死亡/助攻
+		ScoredGames int        `json:"scoredGames"`
+		Score       float64    `json:"score"` // 平均得分,ScoredGames 为0时为0
+	}
+	// Badge 某种行为及出现的对局数
+	Badge struct {
+		Behavior Behavior `json:"behavior"`
+		Games    int      `json:"games"`
 	}
 	IncScoreReason struct {
-		reason ScoreOption
-		incVal float64
+		Reason ScoreOption `json:"reason"`
+		IncVal float64     `json:"incVal"`
 	}
 	ScoreWithReason struct {
 		score   float64
 		reasons []IncScoreReason
 	}
 	ScoreOption string // 得分选项
+	Behavior    string // 对局中的不良行为
 )
 
 const (
-	ScoreOptionFirstBloodKill     ScoreOption = "一血击杀"
-	ScoreOptionFirstBloodAssist   ScoreOption = "一血助攻"
-	ScoreOptionPentaKills         ScoreOption = "五杀"
-	ScoreOptionQuadraKills        ScoreOption = "四杀"
-	ScoreOptionTripleKills        ScoreOption = "三杀"
-	ScoreOptionJoinTeamRateRank   ScoreOption = "参团率排名"
-	ScoreOptionGoldEarnedRank     ScoreOption = "打钱排名"
-	ScoreOptionHurtRank           ScoreOption = "伤害排名"
-	ScoreOptionMoney2hurtRateRank ScoreOption = "金钱转换伤害比排名"
-	ScoreOptionVisionScoreRank    ScoreOption = "视野得分排名"
-	ScoreOptionMinionsKilled      ScoreOption = "补兵"
-	ScoreOptionKillRate           ScoreOption = "击杀占比"
-	ScoreOptionHurtRate           ScoreOption = "伤害占比"
-	ScoreOptionAssistRate         ScoreOption = "助攻占比"
-	ScoreOptionKDAAdjust          ScoreOption = "kda微调"
+	BehaviorLeaver    Behavior = "挂机"
+	BehaviorSurrender Behavior = "发起投降"
+	BehaviorInter     Behavior = "送人头"
 )
 
+const (
+	ScoreOptionFirstBloodKill       ScoreOption = "一血击杀"
+	ScoreOptionFirstBloodAssist     ScoreOption = "一血助攻"
+	ScoreOptionPentaKills           ScoreOption = "五杀"
+	ScoreOptionQuadraKills          ScoreOption = "四杀"
+	ScoreOptionTripleKills          ScoreOption = "三杀"
+	ScoreOptionJoinTeamRateRank     ScoreOption = "参团率排名"
+	ScoreOptionGoldEarnedRank       ScoreOption = "打钱排名"
+	ScoreOptionHurtRank             ScoreOption = "伤害排名"
+	ScoreOptionMoney2hurtRateRank   ScoreOption = "金钱转换伤害比排名"
+	ScoreOptionVisionScoreRank      ScoreOption = "视野得分排名"
+	ScoreOptionMinionsKilled        ScoreOption = "补兵"
+	ScoreOptionKillRate             ScoreOption = "击杀占比"
+	ScoreOptionHurtRate             ScoreOption = "伤害占比"
+	ScoreOptionAssistRate           ScoreOption = "助攻占比"
+	ScoreOptionKDAAdjust            ScoreOption = "kda微调"
+	ScoreOptionLaning               ScoreOption = "对线"
+	ScoreOptionFirstTowerKill       ScoreOption = "一塔击杀"
+	ScoreOptionFirstTowerAssist     ScoreOption = "一塔助攻"
+	ScoreOptionFirstInhibitorKill   ScoreOption = "首个水晶击杀"
+	ScoreOptionFirstInhibitorAssist ScoreOption = "首个水晶助攻"
+	ScoreOptionObjectiveRate        ScoreOption = "战略点伤害占比"
+	ScoreOptionTowerRate            ScoreOption = "推塔占比"
+)
+
+// ChampionStat 玩家该英雄的近期战绩,近期没有用过时返回空
+func (s *UserScore) ChampionStat(championID int) *ChampionStat {
+	for i := range s.Champions {
+		if s.Champions[i].ChampionID == championID {
+			return &s.Champions[i]
+		}
+	}
+	return nil
+}
+
 func NewScoreWithReason(score float64) *ScoreWithReason {
 	return &ScoreWithReason{
 		score:   score,
@@ -50,17 +143,24 @@ func NewScoreWithReason(score float64) *ScoreWithReason {
 func (s *ScoreWithReason) Add(incVal float64, reason ScoreOption) {
 	s.score += incVal
 	s.reasons = append(s.reasons, IncScoreReason{
-		reason: reason,
-		incVal: incVal,
+		Reason: reason,
+		IncVal: incVal,
 	})
 }
 func (s *ScoreWithReason) Value() float64 {
 	return s.score
 }
+
+// Reasons 每一项加分原因
+func (s *ScoreWithReason) Reasons() []IncScoreReason {
+	res := make([]IncScoreReason, len(s.reasons))
+	copy(res, s.reasons)
+	return res
+}
 func (s *ScoreWithReason) Reasons2String() string {
 	sb := strings.Builder{}
 	for _, reason := range s.reasons {
-		sb.WriteString(fmt.Sprintf("%s%.2f,", reason.reason, reason.incVal))
+		sb.WriteString(fmt.Sprintf("%s%.2f,", reason.Reason, reason.IncVal))
 	}
 	return sb.String()
 }
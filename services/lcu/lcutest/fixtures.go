@@ -0,0 +1,400 @@
+package lcutest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+const (
+	ChampSelectConversationID = "lcutest-champ-select@champ-select.pvp.net"
+)
+
+type (
+	// Player 对局中的一名玩家及其数据
+	Player struct {
+		SummonerID  int64
+		Puuid       string
+		Name        string
+		TagLine     string
+		Level       int
+		ChampionID  int
+		TeamID      models.TeamID
+		Lane        models.Lane
+		Role        models.ChampionRole
+		Kills       int
+		Deaths      int
+		Assists     int
+		Damage      int
+		Gold        int
+		VisionScore int
+		Minions     int
+		Win         bool
+		// 推塔及战略点数据
+		ObjectiveDamage int
+		TurretKills     int
+		FirstTowerKill  bool
+		// CausedEarlySurrender 发起了提前投降
+		CausedEarlySurrender bool
+		// 对线阶段 [0-10分钟,10-20分钟] 的每分钟补刀/经验/金钱,为空时不生成timeline数据
+		CsPerMin   [2]float64
+		XpPerMin   [2]float64
+		GoldPerMin [2]float64
+	}
+	// Game 一局比赛
+	Game struct {
+		ID       int64
+		QueueID  models.GameQueueID
+		Mode     models.GameMode
+		Duration int // 秒
+		Created  time.Time
+		Players  []Player
+	}
+)
+
+// NewPlayer 生成一个数据中规中矩的玩家
+func NewPlayer(summonerID int64, teamID models.TeamID) Player {
+	return Player{
+		SummonerID:  summonerID,
+		Puuid:       fmt.Sprintf("puuid-%d", summonerID),
+		Name:        fmt.Sprintf("player%d", summonerID),
+		TagLine:     "test",
+		Level:       100,
+		ChampionID:  int(summonerID%150) + 1,
+		TeamID:      teamID,
+		Lane:        models.LaneMiddle,
+		Role:        models.ChampionRoleSolo,
+		Kills:       5,
+		Deaths:      5,
+		Assists:     5,
+		Damage:      15000,
+		Gold:        10000,
+		VisionScore: 20,
+		Minions:     150,
+		Win:         teamID == models.TeamIDBlue,
+	}
+}
+
+// NewGame 生成一局5v5排位,前5个id为蓝色方并获胜
+func NewGame(gameID int64, created time.Time, summonerIDs ...int64) Game {
+	g := Game{
+		ID:       gameID,
+		QueueID:  models.RankSoleQueueID,
+		Mode:     models.GameModeClassic,
+		Duration: 30 * 60,
+		Created:  created,
+	}
+	for i, id := range summonerIDs {
+		teamID := models.TeamIDBlue
+		if i >= 5 {
+			teamID = models.TeamIDRed
+		}
+		g.Players = append(g.Players, NewPlayer(id, teamID))
+	}
+	return g
+}
+
+func (g Game) participantsJSON(filter func(p Player) bool) ([]map[string]interface{}, []map[string]interface{}) {
+	identities := make([]map[string]interface{}, 0, len(g.Players))
+	participants := make([]map[string]interface{}, 0, len(g.Players))
+	for i, p := range g.Players {
+		if filter != nil && !filter(p) {
+			continue
+		}
+		participantID := i + 1
+		identities = append(identities, map[string]interface{}{
+			"participantId": participantID,
+			"player": map[string]interface{}{
+				"summonerId":   p.SummonerID,
+				"summonerName": p.Name,
+				"puuid":        p.Puuid,
+				"accountId":    p.SummonerID,
+			},
+		})
+		participants = append(participants, map[string]interface{}{
+			"participantId": participantID,
+			"championId":    p.ChampionID,
+			"teamId":        p.TeamID,
+			"stats": map[string]interface{}{
+				"participantId":               participantID,
+				"kills":                       p.Kills,
+				"deaths":                      p.Deaths,
+				"assists":                     p.Assists,
+				"totalDamageDealtToChampions": p.Damage,
+				"goldEarned":                  p.Gold,
+				"visionScore":                 p.VisionScore,
+				"totalMinionsKilled":          p.Minions,
+				"win":                         p.Win,
+				"damageDealtToObjectives":     p.ObjectiveDamage,
+				"turretKills":                 p.TurretKills,
+				"firstTowerKill":              p.FirstTowerKill,
+				"causedEarlySurrender":        p.CausedEarlySurrender,
+			},
+			"timeline": map[string]interface{}{
+				"participantId":      participantID,
+				"lane":               p.Lane,
+				"role":               p.Role,
+				"creepsPerMinDeltas": deltasJSON(p.CsPerMin),
+				"xpPerMinDeltas":     deltasJSON(p.XpPerMin),
+				"goldPerMinDeltas":   deltasJSON(p.GoldPerMin),
+			},
+		})
+	}
+	return identities, participants
+}
+
+func deltasJSON(deltas [2]float64) map[string]float64 {
+	return map[string]float64{"0-10": deltas[0], "10-20": deltas[1]}
+}
+
+func (g Game) json(filter func(p Player) bool) map[string]interface{} {
+	identities, participants := g.participantsJSON(filter)
+	return map[string]interface{}{
+		"gameId":                g.ID,
+		"queueId":               g.QueueID,
+		"gameMode":              g.Mode,
+		"gameType":              models.GameTypeMatch,
+		"mapId":                 models.MapIDClassic,
+		"gameDuration":          g.Duration,
+		"gameCreation":          g.Created.UnixMilli(),
+		"gameCreationDate":      g.Created,
+		"participantIdentities": identities,
+		"participants":          participants,
+	}
+}
+
+// Summary 转换为 /lol-match-history/v1/games/{id} 的返回
+func (g Game) Summary() lcu.GameSummary {
+	summary := lcu.GameSummary{}
+	bts, _ := json.Marshal(g.json(nil))
+	_ = json.Unmarshal(bts, &summary)
+	return summary
+}
+
+// InfoFor 转换为某个玩家战绩列表中的一项,只包含该玩家自己的数据
+func (g Game) InfoFor(puuid string) lcu.GameInfo {
+	info := lcu.GameInfo{}
+	bts, _ := json.Marshal(g.json(func(p Player) bool {
+		return p.Puuid == puuid
+	}))
+	_ = json.Unmarshal(bts, &info)
+	return info
+}
+
+// SetCurrentSummoner 设置当前登录的召唤师
+func (s *Server) SetCurrentSummoner(p Player) {
+	s.AddSummoners(p)
+	s.Handle(http.MethodGet, "/lol-summoner/v1/current-summoner", http.StatusOK, map[string]interface{}{
+		"summonerId":    p.SummonerID,
+		"accountId":     p.SummonerID,
+		"displayName":   p.Name,
+		"gameName":      p.Name,
+		"tagLine":       p.TagLine,
+		"puuid":         p.Puuid,
+		"summonerLevel": p.Level,
+	})
+}
+
+// AddSummoners 注册召唤师,用于按id/puuid/名称查询
+func (s *Server) AddSummoners(players ...Player) {
+	s.mu.Lock()
+	if s.summoners == nil {
+		s.summoners = make(map[int64]Player)
+	}
+	for _, p := range players {
+		s.summoners[p.SummonerID] = p
+	}
+	s.mu.Unlock()
+	for _, p := range players {
+		s.Handle(http.MethodGet, fmt.Sprintf("/lol-summoner/v2/summoners/puuid/%s", p.Puuid), http.StatusOK,
+			summonerJSON(p))
+	}
+	s.HandleFunc(http.MethodGet, "/lol-summoner/v2/summoners", s.serveSummonersByIDs)
+	s.HandleFunc(http.MethodGet, "/lol-summoner/v1/summoners", s.serveSummonerByName)
+	s.HandleFunc(http.MethodPost, "/lol-summoner/v1/summoners/aliases", s.serveSummonerAliases)
+}
+
+func summonerJSON(p Player) map[string]interface{} {
+	return map[string]interface{}{
+		"summonerId":    p.SummonerID,
+		"accountId":     p.SummonerID,
+		"displayName":   p.Name,
+		"internalName":  p.Name,
+		"gameName":      p.Name,
+		"tagLine":       p.TagLine,
+		"puuid":         p.Puuid,
+		"summonerLevel": p.Level,
+	}
+}
+
+func (s *Server) serveSummonersByIDs(w http.ResponseWriter, r *http.Request) {
+	ids := strings.Trim(r.URL.Query().Get("ids"), "[]")
+	list := make([]map[string]interface{}, 0, 1)
+	s.mu.Lock()
+	for _, idStr := range strings.Split(ids, ",") {
+		id, _ := strconv.ParseInt(strings.TrimSpace(idStr), 10, 64)
+		if p, ok := s.summoners[id]; ok {
+			list = append(list, summonerJSON(p))
+		}
+	}
+	s.mu.Unlock()
+	writeBody(w, http.StatusOK, list)
+}
+
+func (s *Server) serveSummonerByName(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.summoners {
+		if strings.EqualFold(p.Name, name) || strings.EqualFold(p.Name+"#"+p.TagLine, name) {
+			writeBody(w, http.StatusOK, summonerJSON(p))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "RPC_ERROR", "summoner not found")
+}
+
+func (s *Server) serveSummonerAliases(w http.ResponseWriter, r *http.Request) {
+	aliases := make([]struct {
+		GameName string `json:"gameName"`
+		TagLine  string `json:"tagLine"`
+	}, 0, 1)
+	if err := json.NewDecoder(r.Body).Decode(&aliases); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	list := make([]map[string]interface{}, 0, len(aliases))
+	s.mu.Lock()
+	ids := make([]int64, 0, len(s.summoners))
+	for id := range s.summoners {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, alias := range aliases {
+		for _, id := range ids {
+			p := s.summoners[id]
+			if strings.EqualFold(p.Name, alias.GameName) && strings.EqualFold(p.TagLine, alias.TagLine) {
+				list = append(list, summonerJSON(p))
+			}
+		}
+	}
+	s.mu.Unlock()
+	writeBody(w, http.StatusOK, list)
+}
+
+// SetGames 注册对局详情以及每个玩家的战绩列表(按时间倒序)
+func (s *Server) SetGames(games ...Game) {
+	byPlayer := make(map[string][]Game)
+	for _, g := range games {
+		s.Handle(http.MethodGet, fmt.Sprintf("/lol-match-history/v1/games/%d", g.ID), http.StatusOK, g.json(nil))
+		for _, p := range g.Players {
+			byPlayer[p.Puuid] = append(byPlayer[p.Puuid], g)
+		}
+	}
+	for puuid, list := range byPlayer {
+		puuid := puuid
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Created.After(list[j].Created)
+		})
+		list := list
+		s.HandleFunc(http.MethodGet, fmt.Sprintf("/lol-match-history/v1/products/lol/%s/matches", puuid),
+			func(w http.ResponseWriter, r *http.Request) {
+				begin, _ := strconv.Atoi(r.URL.Query().Get("begIndex"))
+				end, err := strconv.Atoi(r.URL.Query().Get("endIndex"))
+				if err != nil || end > len(list) {
+					end = len(list)
+				}
+				if begin > end {
+					begin = end
+				}
+				infos := make([]map[string]interface{}, 0, end-begin)
+				for _, g := range list[begin:end] {
+					infos = append(infos, g.json(func(p Player) bool {
+						return p.Puuid == puuid
+					}))
+				}
+				writeBody(w, http.StatusOK, map[string]interface{}{
+					"games": map[string]interface{}{
+						"gameCount":      len(infos),
+						"gameIndexBegin": begin,
+						"gameIndexEnd":   end,
+						"games":          infos,
+					},
+				})
+			})
+	}
+}
+
+// SetChampSelectConversation 设置选人聊天组,成员按顺序加入房间
+func (s *Server) SetChampSelectConversation(summonerIDs ...int64) {
+	s.Handle(http.MethodGet, "/lol-chat/v1/conversations", http.StatusOK, []map[string]interface{}{
+		{"id": ChampSelectConversationID, "type": models.GameStatusChampionSelect},
+	})
+	msgs := make([]map[string]interface{}, 0, len(summonerIDs))
+	for i, id := range summonerIDs {
+		msgs = append(msgs, map[string]interface{}{
+			"id":             strconv.Itoa(i),
+			"body":           lcu.JoinedRoomMsg,
+			"type":           lcu.ConversationMsgTypeSystem,
+			"fromSummonerId": id,
+			"timestamp":      time.Now(),
+		})
+	}
+	path := fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", ChampSelectConversationID)
+	s.Handle(http.MethodGet, path, http.StatusOK, msgs)
+	s.Handle(http.MethodPost, path, http.StatusOK, map[string]interface{}{"type": "chat"})
+}
+
+// ChatMessages 返回发送到选人聊天组的消息
+func (s *Server) ChatMessages() []string {
+	path := fmt.Sprintf("/lol-chat/v1/conversations/%s/messages", ChampSelectConversationID)
+	list := make([]string, 0, 5)
+	for _, req := range s.Requests(http.MethodPost, path) {
+		msg := struct {
+			Body string `json:"body"`
+		}{}
+		_ = json.Unmarshal(req.Body, &msg)
+		list = append(list, msg.Body)
+	}
+	return list
+}
+
+// SetGameFlowSession 设置游戏会话,teamOne 为蓝色方
+func (s *Server) SetGameFlowSession(phase models.GameFlow, teamOne, teamTwo []int64) {
+	toUsers := func(ids []int64, teamID models.TeamIDStr) []map[string]interface{} {
+		users := make([]map[string]interface{}, 0, len(ids))
+		for _, id := range ids {
+			users = append(users, map[string]interface{}{
+				"summonerId": id,
+				"puuid":      fmt.Sprintf("puuid-%d", id),
+				"teamId":     teamID,
+			})
+		}
+		return users
+	}
+	s.Handle(http.MethodGet, "/lol-gameflow/v1/session", http.StatusOK, map[string]interface{}{
+		"phase": phase,
+		"gameData": map[string]interface{}{
+			"queue":   map[string]interface{}{"id": models.RankSoleQueueID, "gameMode": models.GameModeClassic},
+			"teamOne": toUsers(teamOne, models.TeamIDStrBlue),
+			"teamTwo": toUsers(teamTwo, models.TeamIDStrRed),
+		},
+	})
+}
+
+// SetChampSelectSession 设置选人会话
+func (s *Server) SetChampSelectSession(session interface{}) {
+	s.Handle(http.MethodGet, "/lol-champ-select/v1/session", http.StatusOK, session)
+}
+
+// AcceptReadyCheck 注册接受对局接口
+func (s *Server) AcceptReadyCheck() {
+	s.Handle(http.MethodPost, "/lol-matchmaking/v1/ready-check/accept", http.StatusNoContent, nil)
+}
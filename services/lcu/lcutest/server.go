@@ -0,0 +1,259 @@
+// Package lcutest 提供一个本地的假lcu服务,用于在没有lol客户端的情况下测试
+package lcutest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultToken = "lcutest-token"
+	authUser     = "riot"
+)
+
+type (
+	// Server 带basic auth的本地tls服务,按脚本返回接口数据并通过websocket推送wamp事件
+	Server struct {
+		*httptest.Server
+		Token string
+
+		mu         sync.Mutex
+		routes     map[string]http.HandlerFunc
+		requests   []Request
+		summoners  map[int64]Player
+		conns      map[*websocket.Conn]bool
+		subscribed chan struct{}
+		upgrader   websocket.Upgrader
+	}
+	// Request 服务收到的请求记录
+	Request struct {
+		Method string
+		Path   string
+		Query  url.Values
+		Body   []byte
+	}
+)
+
+// NewServer 启动一个假lcu服务,调用方负责 Close
+func NewServer() *Server {
+	s := &Server{
+		Token:      DefaultToken,
+		routes:     make(map[string]http.HandlerFunc),
+		conns:      make(map[*websocket.Conn]bool),
+		subscribed: make(chan struct{}),
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Port 服务监听的端口
+func (s *Server) Port() int {
+	u, _ := url.Parse(s.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return port
+}
+
+// Close 关闭所有websocket连接并停止服务
+func (s *Server) Close() {
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.Server.Close()
+}
+
+// Handle 设置接口固定返回, body 为 string/[]byte 时原样返回,否则json序列化
+func (s *Server) Handle(method, path string, status int, body interface{}) {
+	s.HandleFunc(method, path, func(w http.ResponseWriter, r *http.Request) {
+		writeBody(w, status, body)
+	})
+}
+
+// HandleFunc 设置接口处理函数, path 不包含query
+func (s *Server) HandleFunc(method, path string, fn http.HandlerFunc) {
+	s.mu.Lock()
+	s.routes[routeKey(method, path)] = fn
+	s.mu.Unlock()
+}
+
+// Requests 返回收到的匹配请求, method/path 为空时不过滤
+func (s *Server) Requests(method, path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Request, 0, len(s.requests))
+	for _, req := range s.requests {
+		if (method == "" || req.Method == method) && (path == "" || req.Path == path) {
+			list = append(list, req)
+		}
+	}
+	return list
+}
+
+// WaitRequest 等待直到收到匹配的请求
+func (s *Server) WaitRequest(method, path string, timeout time.Duration) (Request, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if list := s.Requests(method, path); len(list) > 0 {
+			return list[0], nil
+		}
+		if time.Now().After(deadline) {
+			return Request{}, errors.Errorf("等待请求超时 %s %s", method, path)
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+}
+
+// WaitSubscribed 等待客户端订阅 OnJsonApiEvent
+func (s *Server) WaitSubscribed(timeout time.Duration) error {
+	select {
+	case <-s.subscribed:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("等待订阅超时")
+	}
+}
+
+// Push 向所有已订阅的连接推送事件
+func (s *Server) Push(uri string, eventType lcu.EventType, data interface{}) error {
+	payload := map[string]interface{}{
+		"data":      data,
+		"eventType": eventType,
+		"uri":       uri,
+	}
+	bts, err := json.Marshal([]interface{}{lcu.WampOpcodeEvent, lcu.OnJsonApiEventTopic, payload})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.conns) == 0 {
+		return errors.New("没有已订阅的连接")
+	}
+	for conn := range s.conns {
+		if err = conn.WriteMessage(websocket.TextMessage, bts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PushGameFlow 推送游戏流程变化事件
+func (s *Server) PushGameFlow(phase string) error {
+	return s.Push("/lol-gameflow/v1/gameflow-phase", lcu.EventTypeUpdate, phase)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	user, pwd, ok := r.BasicAuth()
+	if !ok || user != authUser || subtle.ConstantTimeCompare([]byte(pwd), []byte(s.Token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid credentials")
+		return
+	}
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWs(w, r)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Body:   body,
+	})
+	fn, ok := s.routes[routeKey(r.Method, r.URL.Path)]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "RESOURCE_NOT_FOUND",
+			fmt.Sprintf("No route for %s %s", r.Method, r.URL.Path))
+		return
+	}
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+	fn(w, r)
+}
+
+func (s *Server) serveWs(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg, err := lcu.DecodeWampMessage(message)
+		if err != nil || msg.Topic != lcu.OnJsonApiEventTopic {
+			continue
+		}
+		s.mu.Lock()
+		switch msg.Opcode {
+		case lcu.WampOpcodeSubscribe:
+			if !s.conns[conn] {
+				s.conns[conn] = true
+				select {
+				case <-s.subscribed:
+				default:
+					close(s.subscribed)
+				}
+			}
+		case lcu.WampOpcodeUnsubscribe:
+			delete(s.conns, conn)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+func writeBody(w http.ResponseWriter, status int, body interface{}) {
+	var bts []byte
+	switch v := body.(type) {
+	case nil:
+	case []byte:
+		bts = v
+	case string:
+		bts = []byte(v)
+	default:
+		bts, _ = json.Marshal(v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if len(bts) == 0 {
+		if status == http.StatusOK {
+			status = http.StatusNoContent
+		}
+		w.WriteHeader(status)
+		return
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(bts)
+}
+
+func writeError(w http.ResponseWriter, status int, errorCode, message string) {
+	writeBody(w, status, lcu.CommonResp{
+		ErrorCode:  errorCode,
+		HttpStatus: status,
+		Message:    message,
+	})
+}
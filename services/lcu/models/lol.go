@@ -11,6 +11,7 @@ type (
 	Champion      int    // 英雄
 	Lane          string // 位置
 	ChampionRole  string // 英雄角色
+	Position      string // 对局中实际的位置 上单/打野/中单/adc/辅助
 	GameFlow      string // 游戏状态
 	MapID         int    // 地图id
 	TeamID        int    // 队伍id
@@ -91,20 +92,34 @@ const (
 const (
 	SpellPingZhang Spell = 21 // 屏障
 	SpellShanXian  Spell = 4  // 闪现
+	SpellChengJie  Spell = 11 // 惩戒
 )
 
 // 位置
 const (
-	LaneTop    Lane = "JUNGLE" // 上路
+	LaneTop    Lane = "TOP"    // 上路
 	LaneJungle Lane = "JUNGLE" // 打野
 	LaneMiddle Lane = "MIDDLE" // 中路
+	LaneMid    Lane = "MID"    // 中路 部分版本的写法
 	LaneBottom Lane = "BOTTOM" // 下路
+	LaneBot    Lane = "BOT"    // 下路 部分版本的写法
 )
 
 // 英雄角色
 const (
-	ChampionRoleSolo    ChampionRole = "SOLE"        // 单人路
+	ChampionRoleSolo    ChampionRole = "SOLO"        // 单人路
+	ChampionRoleDuo     ChampionRole = "DUO"         // 双人路 无法区分adc和辅助
 	ChampionRoleSupport ChampionRole = "DUO_SUPPORT" // 辅助
 	ChampionRoleADC     ChampionRole = "DUO_CARRY"   // adc
 	ChampionRoleNone    ChampionRole = "NONE"        // 无 一般是打野
 )
+
+// 对局中实际的位置
+const (
+	PositionUnknown Position = ""
+	PositionTop     Position = "top"
+	PositionJungle  Position = "jungle"
+	PositionMid     Position = "mid"
+	PositionADC     Position = "adc"
+	PositionSupport Position = "support"
+)
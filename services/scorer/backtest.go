@@ -0,0 +1,234 @@
+package scorer
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+// backtestBins 校准表分组,按优势方的预测胜率从50%到100%分为10组
+const backtestBins = 10
+
+type (
+	// Backtester 按时间顺序回放本地对局,每个玩家只使用该局之前的对局计算得分,检验双方得分差能否预测胜负
+	Backtester struct {
+		cfg        *conf.CalcScoreConf
+		scorers    []Scorer
+		minHistory int
+		scale      float64
+		games      []backtestGame
+	}
+	backtestGame struct {
+		id       int64
+		mode     string
+		creation time.Time
+		blueWin  bool
+		players  []backtestPlayer
+	}
+	backtestPlayer struct {
+		puuid  string
+		blue   bool
+		scores []float64 // 各评分算法的得分,与 scorers 顺序相同
+	}
+	backtestBinSum struct {
+		games     int
+		wins      int
+		predicted float64
+	}
+	// backtestSum 一种胜率换算方式的累计结果
+	backtestSum struct {
+		games   int
+		correct int
+		brier   float64
+		bins    [backtestBins]backtestBinSum
+	}
+	// BacktestResult 一个评分算法的回测结果,按 scale 换算胜率,WinProbability 为按配置中的 winProbability 模型换算的结果
+	BacktestResult struct {
+		Scorer string
+		BacktestModelResult
+		WinProbability BacktestModelResult
+	}
+	// BacktestModelResult 一种胜率换算方式的回测结果
+	BacktestModelResult struct {
+		Games    int     // 双方都有历史得分的对局数
+		Correct  int     // 预测胜率高的一方获胜的对局数
+		Accuracy float64 // Correct/Games
+		Brier    float64 // 预测胜率与实际结果的均方误差,越小越好,全部预测50%时为0.25
+		Bins     []BacktestBin
+	}
+	// BacktestBin 校准表的一组,预测胜率与实际胜率越接近越好
+	BacktestBin struct {
+		Low, High float64 // 优势方预测胜率范围
+		Games     int
+		Predicted float64 // 平均预测胜率
+		Actual    float64 // 优势方实际胜率
+	}
+)
+
+// NewBacktester minHistory 为玩家至少需要的历史对局数,scale 为得分差换算胜率的尺度,得分差为scale时预测胜率约73%
+func NewBacktester(cfg *conf.CalcScoreConf, minHistory int, scale float64) *Backtester {
+	return &Backtester{
+		cfg:        cfg,
+		scorers:    All(cfg),
+		minHistory: minHistory,
+		scale:      scale,
+	}
+}
+
+// Add 计算一局中所有玩家在各评分算法下的得分,不属于任何模式或时长不足的对局忽略
+func (b *Backtester) Add(gameSummary *lcu.GameSummary) {
+	mode := b.cfg.ModeOf(gameSummary.QueueId, string(gameSummary.GameMode))
+	if mode == nil || gameSummary.GameDuration < mode.MinDurationSec {
+		return
+	}
+	game := backtestGame{
+		id:       gameSummary.GameId,
+		mode:     mode.Name,
+		creation: gameSummary.GameCreationDate,
+		players:  make([]backtestPlayer, 0, len(gameSummary.ParticipantIdentities)),
+	}
+	for _, identity := range gameSummary.ParticipantIdentities {
+		participant := gameSummary.FindParticipant(identity.Player.Puuid)
+		if participant == nil {
+			continue
+		}
+		player := backtestPlayer{
+			puuid:  identity.Player.Puuid,
+			blue:   participant.TeamId == models.TeamIDBlue,
+			scores: make([]float64, len(b.scorers)),
+		}
+		if player.blue {
+			game.blueWin = participant.Stats.Win
+		}
+		for i, s := range b.scorers {
+			score, err := s.Score(player.puuid, gameSummary)
+			if err != nil {
+				return
+			}
+			player.scores[i] = score.Value()
+		}
+		game.players = append(game.players, player)
+	}
+	b.games = append(b.games, game)
+}
+
+// Result 各评分算法的回测结果,顺序与 All 相同
+func (b *Backtester) Result() []BacktestResult {
+	sort.Slice(b.games, func(i, j int) bool {
+		return b.games[i].creation.Before(b.games[j].creation)
+	})
+	scaled := make([]backtestSum, len(b.scorers))
+	configured := make([]backtestSum, len(b.scorers))
+	// 模式名+puuid -> 该玩家之前的对局得分,按时间倒序
+	history := make(map[string][][]lcu.GameScore)
+	for _, game := range b.games {
+		for i := range b.scorers {
+			blue, red := b.teamScore(history, game, i, true), b.teamScore(history, game, i, false)
+			if math.IsNaN(blue) || math.IsNaN(red) {
+				continue
+			}
+			scaled[i].add(WinProbability(blue-red, b.scale), game.blueWin)
+			configured[i].add(TeamWinProbability(blue, red, b.cfg.WinProbability), game.blueWin)
+		}
+		for _, player := range game.players {
+			key := game.mode + player.puuid
+			if history[key] == nil {
+				history[key] = make([][]lcu.GameScore, len(b.scorers))
+			}
+			for i, score := range player.scores {
+				list := append([]lcu.GameScore{{GameID: game.id, GameCreation: game.creation, Score: score}},
+					history[key][i]...)
+				if len(list) > b.cfg.Weighting.HistoryDepth {
+					list = list[:b.cfg.Weighting.HistoryDepth]
+				}
+				history[key][i] = list
+			}
+		}
+	}
+	results := make([]BacktestResult, len(b.scorers))
+	for i, s := range b.scorers {
+		results[i] = BacktestResult{
+			Scorer:              s.Name(),
+			BacktestModelResult: scaled[i].result(),
+			WinProbability:      configured[i].result(),
+		}
+	}
+	return results
+}
+
+// add 累计一局的预测,p 为蓝方预测胜率
+func (sum *backtestSum) add(p float64, blueWin bool) {
+	sum.games++
+	if (p > 0.5) == blueWin && p != 0.5 {
+		sum.correct++
+	}
+	actual := 0.0
+	if blueWin {
+		actual = 1
+	}
+	sum.brier += (p - actual) * (p - actual)
+	// 按优势方统计
+	favored, favoredWin := p, blueWin
+	if p < 0.5 {
+		favored, favoredWin = 1-p, !blueWin
+	}
+	bin := &sum.bins[int(math.Min((favored-0.5)*2*backtestBins, backtestBins-1))]
+	bin.games++
+	bin.predicted += favored
+	if favoredWin {
+		bin.wins++
+	}
+}
+
+func (sum *backtestSum) result() BacktestModelResult {
+	res := BacktestModelResult{Games: sum.games, Correct: sum.correct}
+	if res.Games > 0 {
+		res.Accuracy = float64(res.Correct) / float64(res.Games)
+		res.Brier = sum.brier / float64(res.Games)
+	}
+	for j, bin := range sum.bins {
+		item := BacktestBin{
+			Low:   0.5 + float64(j)*0.5/backtestBins,
+			High:  0.5 + float64(j+1)*0.5/backtestBins,
+			Games: bin.games,
+		}
+		if bin.games > 0 {
+			item.Predicted = bin.predicted / float64(bin.games)
+			item.Actual = float64(bin.wins) / float64(bin.games)
+		}
+		res.Bins = append(res.Bins, item)
+	}
+	return res
+}
+
+// teamScore 一方在该局之前的平均得分,没有玩家达到 minHistory 时返回NaN
+func (b *Backtester) teamScore(history map[string][][]lcu.GameScore, game backtestGame, scorerIdx int,
+	blue bool) float64 {
+	total, count := 0.0, 0
+	for _, player := range game.players {
+		if player.blue != blue {
+			continue
+		}
+		scores := history[game.mode+player.puuid]
+		if scores == nil || len(scores[scorerIdx]) == 0 || len(scores[scorerIdx]) < b.minHistory {
+			continue
+		}
+		// Weigh 会写入权重,使用副本
+		list := append([]lcu.GameScore(nil), scores[scorerIdx]...)
+		total += Weigh(list, b.cfg.Weighting, game.creation).Score
+		count++
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return total / float64(count)
+}
+
+// WinProbability 根据双方平均得分差换算的胜率,scale 越大得分差的影响越小
+func WinProbability(diff float64, scale float64) float64 {
+	return 1 / (1 + math.Exp(-diff/scale))
+}
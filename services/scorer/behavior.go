@@ -0,0 +1,57 @@
+package scorer
+
+import (
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+)
+
+// 标记的显示顺序
+var behaviorOrder = []lcu.Behavior{lcu.BehaviorLeaver, lcu.BehaviorSurrender, lcu.BehaviorInter}
+
+// GameBehaviors 玩家在一局中的挂机/发起投降/送人头行为,未开启或不在对局中时返回空
+func GameBehaviors(gameSummary *lcu.GameSummary, puuid string, cfg conf.BehaviorConf) []lcu.Behavior {
+	if !cfg.Enabled {
+		return nil
+	}
+	ctx, err := newGameContext(puuid, gameSummary)
+	if err != nil {
+		return nil
+	}
+	stats := ctx.user.Stats
+	behaviors := make([]lcu.Behavior, 0, len(behaviorOrder))
+	// 伤害和金钱都几乎为0
+	if ctx.totalHurt > 0 && ctx.totalMoney > 0 &&
+		float64(stats.TotalDamageDealtToChampions)*100/float64(ctx.totalHurt) < cfg.LeaverDamageRate &&
+		float64(stats.GoldEarned)*100/float64(ctx.totalMoney) < cfg.LeaverGoldRate {
+		behaviors = append(behaviors, lcu.BehaviorLeaver)
+	}
+	if stats.CausedEarlySurrender {
+		behaviors = append(behaviors, lcu.BehaviorSurrender)
+	}
+	if stats.Deaths >= cfg.InterDeaths &&
+		float64(stats.Kills+stats.Assists)/float64(stats.Deaths) < cfg.InterKDA {
+		behaviors = append(behaviors, lcu.BehaviorInter)
+	}
+	return behaviors
+}
+
+// Badges 统计玩家在各局的行为,出现次数达到 minGames 的行为作为玩家的标记
+// gameSummaryList 应包括时长不足的对局,提前投降和挂机大多发生在这些对局中
+func Badges(gameSummaryList []lcu.GameSummary, puuid string, cfg conf.BehaviorConf) []lcu.Badge {
+	if !cfg.Enabled {
+		return nil
+	}
+	counts := make(map[lcu.Behavior]int, len(behaviorOrder))
+	for i := range gameSummaryList {
+		for _, behavior := range GameBehaviors(&gameSummaryList[i], puuid, cfg) {
+			counts[behavior]++
+		}
+	}
+	var badges []lcu.Badge
+	for _, behavior := range behaviorOrder {
+		if counts[behavior] >= cfg.MinGames {
+			badges = append(badges, lcu.Badge{Behavior: behavior, Games: counts[behavior]})
+		}
+	}
+	return badges
+}
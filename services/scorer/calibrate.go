@@ -0,0 +1,150 @@
+package scorer
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+)
+
+// DefaultCalibratePercentiles 默认前10%/30%/60%/80%的玩家分别达到前四档马匹
+var DefaultCalibratePercentiles = [4]float64{10, 30, 60, 80}
+
+type (
+	// Calibrator 用当前评分算法计算本地对局中每个玩家的得分,根据得分分布给出马匹分数线
+	Calibrator struct {
+		cfg      *conf.CalcScoreConf
+		scorer   Scorer
+		minGames int
+		games    int
+		// 模式名 -> puuid -> 该玩家每一局的得分
+		scores map[string]map[string][]lcu.GameScore
+	}
+	// Calibration 一个游戏模式的得分分布及建议的马匹分数线
+	Calibration struct {
+		Mode      string
+		Players   int       // 对局数达到 minGames 的玩家数
+		Games     int       // 参与计算的对局数
+		Quantiles []float64 // 从前10%到前90%的玩家得分
+		Horse     [5]conf.HorseScoreConf
+	}
+)
+
+// NewCalibrator 对局数少于minGames的玩家不参与统计
+func NewCalibrator(cfg *conf.CalcScoreConf, minGames int) *Calibrator {
+	return &Calibrator{
+		cfg:      cfg,
+		scorer:   New(cfg),
+		minGames: minGames,
+		scores:   make(map[string]map[string][]lcu.GameScore),
+	}
+}
+
+// Add 计算一局中所有玩家的得分,不属于任何模式或时长不足的对局忽略
+func (c *Calibrator) Add(gameSummary *lcu.GameSummary) {
+	mode := c.cfg.ModeOf(gameSummary.QueueId, string(gameSummary.GameMode))
+	if mode == nil || gameSummary.GameDuration < mode.MinDurationSec {
+		return
+	}
+	players := c.scores[mode.Name]
+	if players == nil {
+		players = make(map[string][]lcu.GameScore)
+		c.scores[mode.Name] = players
+	}
+	c.games++
+	for _, identity := range gameSummary.ParticipantIdentities {
+		puuid := identity.Player.Puuid
+		score, err := c.scorer.Score(puuid, gameSummary)
+		if err != nil {
+			continue
+		}
+		players[puuid] = append(players[puuid], lcu.GameScore{
+			GameID:       gameSummary.GameId,
+			GameCreation: gameSummary.GameCreationDate,
+			Score:        score.Value(),
+		})
+	}
+}
+
+// Games 参与计算的对局数
+func (c *Calibrator) Games() int {
+	return c.games
+}
+
+// Result 按模式配置的顺序返回各模式的得分分布,percentiles 为前四档马匹对应的玩家百分比
+// 没有足够玩家的模式不返回,第五档分数线保持原配置
+func (c *Calibrator) Result(percentiles [4]float64, now time.Time) []Calibration {
+	res := make([]Calibration, 0, len(c.cfg.Modes))
+	for i := range c.cfg.Modes {
+		mode := &c.cfg.Modes[i]
+		players := c.scores[mode.Name]
+		scores := make([]float64, 0, len(players))
+		gameIDs := make(map[int64]struct{})
+		for _, list := range players {
+			if len(list) < c.minGames {
+				continue
+			}
+			sort.Slice(list, func(i, j int) bool {
+				return list[i].GameCreation.After(list[j].GameCreation)
+			})
+			for _, game := range list {
+				gameIDs[game.GameID] = struct{}{}
+			}
+			scores = append(scores, Weigh(list, c.cfg.Weighting, now).Score)
+		}
+		if len(scores) == 0 {
+			continue
+		}
+		sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+		calibration := Calibration{
+			Mode:    mode.Name,
+			Players: len(scores),
+			Games:   len(gameIDs),
+			Horse:   c.cfg.HorseOf(mode),
+		}
+		for p := 10.0; p < 100; p += 10 {
+			calibration.Quantiles = append(calibration.Quantiles, topPercentile(scores, p))
+		}
+		for j, p := range percentiles {
+			calibration.Horse[j].Score = topPercentile(scores, p)
+		}
+		res = append(res, calibration)
+	}
+	return res
+}
+
+// topPercentile 前p%的玩家能达到的最低得分,向下取整到0.1
+func topPercentile(desc []float64, p float64) float64 {
+	idx := int(math.Ceil(p/100*float64(len(desc)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(desc) {
+		idx = len(desc) - 1
+	}
+	return math.Floor(desc[idx]*10) / 10
+}
+
+// ApplyCalibration 把各模式建议的分数线写入配置,对局最多的模式同时作为全局分数线
+func ApplyCalibration(cfg *conf.CalcScoreConf, list []Calibration) {
+	// 模式列表可能与其他配置共用
+	cfg.Modes = append([]conf.ModeScoreConf(nil), cfg.Modes...)
+	var most *Calibration
+	for i := range list {
+		calibration := &list[i]
+		mode := cfg.ModeByName(calibration.Mode)
+		if mode == nil {
+			continue
+		}
+		horse := calibration.Horse
+		mode.Horse = &horse
+		if most == nil || calibration.Games > most.Games {
+			most = calibration
+		}
+	}
+	if most != nil {
+		cfg.Horse = most.Horse
+	}
+}
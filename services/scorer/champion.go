@@ -0,0 +1,59 @@
+package scorer
+
+import (
+	"sort"
+
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+)
+
+// ChampionStats 按英雄统计玩家的近期战绩,按对局数倒序
+// gameList 为玩家自己的全部近期战绩,gameScoreList 为当前模式参与计算的每一局得分
+func ChampionStats(gameList []lcu.GameInfo, gameScoreList []lcu.GameScore) []lcu.ChampionStat {
+	stats := make(map[int]*lcu.ChampionStat)
+	statOf := func(championID int) *lcu.ChampionStat {
+		stat := stats[championID]
+		if stat == nil {
+			stat = &lcu.ChampionStat{ChampionID: championID}
+			stats[championID] = stat
+		}
+		return stat
+	}
+	for _, game := range gameList {
+		if game.GameDuration < remakeDurationSec || len(game.Participants) == 0 {
+			continue
+		}
+		participant := game.Participants[0]
+		stat := statOf(int(participant.ChampionId))
+		stat.Games++
+		if participant.Stats.Win {
+			stat.Wins++
+		}
+		stat.KDA[0] += float64(participant.Stats.Kills)
+		stat.KDA[1] += float64(participant.Stats.Deaths)
+		stat.KDA[2] += float64(participant.Stats.Assists)
+	}
+	for _, game := range gameScoreList {
+		stat := statOf(game.ChampionID)
+		stat.ScoredGames++
+		stat.Score += game.Score
+	}
+	res := make([]lcu.ChampionStat, 0, len(stats))
+	for _, stat := range stats {
+		if stat.Games > 0 {
+			for i := range stat.KDA {
+				stat.KDA[i] /= float64(stat.Games)
+			}
+		}
+		if stat.ScoredGames > 0 {
+			stat.Score /= float64(stat.ScoredGames)
+		}
+		res = append(res, *stat)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Games != res[j].Games {
+			return res[i].Games > res[j].Games
+		}
+		return res[i].ChampionID < res[j].ChampionID
+	})
+	return res
+}
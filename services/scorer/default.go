@@ -0,0 +1,403 @@
+package scorer
+
+import (
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+// DefaultScorer 默认评分算法,各项加分见 conf.CalcScoreConf
+type DefaultScorer struct {
+	conf *conf.CalcScoreConf
+}
+
+func NewDefaultScorer(cfg *conf.CalcScoreConf) *DefaultScorer {
+	return &DefaultScorer{conf: cfg}
+}
+
+func (s *DefaultScorer) Name() string {
+	return conf.DefaultScorerName
+}
+
+func (s *DefaultScorer) Score(puuid string, gameSummary *lcu.GameSummary) (*lcu.ScoreWithReason, error) {
+	calcScoreConf := s.conf
+	gameScore := lcu.NewScoreWithReason(DefaultScore)
+	ctx, err := newGameContext(puuid, gameSummary)
+	if err != nil {
+		return nil, err
+	}
+	totalKill := ctx.totalKill
+	totalAssist := ctx.totalAssist
+	totalHurt := ctx.totalHurt
+	totalMoney := ctx.totalMoney
+	userParticipant := ctx.user
+	isSupportRole := ctx.position() == models.PositionSupport
+	mode := s.conf.ModeOf(gameSummary.QueueId, string(gameSummary.GameMode))
+	// 对局所属模式中关闭的得分项不计算
+	enabled := func(metric string) bool {
+		return mode == nil || mode.MetricEnabled(metric)
+	}
+	norm := s.newRoleNormalizer(ctx)
+	weight := norm.weight()
+	// 按位置权重加分,权重为0时不计算该项
+	addWeighted := func(incVal float64, w float64, reason lcu.ScoreOption) {
+		if w != 0 {
+			gameScore.Add(incVal*w, reason)
+		}
+	}
+	if enabled(conf.MetricFirstBlood) {
+		// 一血击杀
+		if userParticipant.Stats.FirstBloodKill {
+			gameScore.Add(calcScoreConf.FirstBlood[0], lcu.ScoreOptionFirstBloodKill)
+			// 一血助攻
+		} else if userParticipant.Stats.FirstBloodAssist {
+			gameScore.Add(calcScoreConf.FirstBlood[1], lcu.ScoreOptionFirstBloodAssist)
+		}
+	}
+	if enabled(conf.MetricMultiKill) {
+		// 五杀
+		if userParticipant.Stats.PentaKills > 0 {
+			gameScore.Add(calcScoreConf.PentaKills[0], lcu.ScoreOptionPentaKills)
+			// 四杀
+		} else if userParticipant.Stats.QuadraKills > 0 {
+			gameScore.Add(calcScoreConf.QuadraKills[0], lcu.ScoreOptionQuadraKills)
+			// 三杀
+		} else if userParticipant.Stats.TripleKills > 0 {
+			gameScore.Add(calcScoreConf.TripleKills[0], lcu.ScoreOptionTripleKills)
+		}
+	}
+	// 参团率
+	if enabled(conf.MetricJoinTeamRate) && totalKill > 0 {
+		joinTeamRateRank := 1
+		userJoinTeamKillRate := float64(userParticipant.Stats.Assists+userParticipant.Stats.Kills) / float64(
+			totalKill)
+		memberJoinTeamKillRates := listMemberJoinTeamKillRates(ctx.members, totalKill)
+		for _, rate := range memberJoinTeamKillRates {
+			if rate > userJoinTeamKillRate {
+				joinTeamRateRank++
+			}
+		}
+		if joinTeamRateRank == 1 {
+			gameScore.Add(calcScoreConf.JoinTeamRateRank[0], lcu.ScoreOptionJoinTeamRateRank)
+		} else if joinTeamRateRank == 2 {
+			gameScore.Add(calcScoreConf.JoinTeamRateRank[1], lcu.ScoreOptionJoinTeamRateRank)
+		} else if joinTeamRateRank == 4 {
+			gameScore.Add(-calcScoreConf.JoinTeamRateRank[2], lcu.ScoreOptionJoinTeamRateRank)
+		} else if joinTeamRateRank == 5 {
+			gameScore.Add(-calcScoreConf.JoinTeamRateRank[3], lcu.ScoreOptionJoinTeamRateRank)
+		}
+	}
+	// 获取金钱
+	if enabled(conf.MetricGoldEarned) && totalMoney > 0 {
+		moneyRank := 1
+		userMoney := userParticipant.Stats.GoldEarned
+		memberMoneyList := listMemberMoney(ctx.members)
+		for _, v := range memberMoneyList {
+			if v > userMoney {
+				moneyRank++
+			}
+		}
+		// 未按位置修正时,辅助打钱少不扣分
+		if moneyRank == 1 {
+			addWeighted(calcScoreConf.GoldEarnedRank[0], weight.Gold, lcu.ScoreOptionGoldEarnedRank)
+		} else if moneyRank == 2 {
+			addWeighted(calcScoreConf.GoldEarnedRank[1], weight.Gold, lcu.ScoreOptionGoldEarnedRank)
+		} else if moneyRank == 4 && (norm.enabled() || !isSupportRole) {
+			addWeighted(-calcScoreConf.GoldEarnedRank[2], weight.Gold, lcu.ScoreOptionGoldEarnedRank)
+		} else if moneyRank == 5 && (norm.enabled() || !isSupportRole) {
+			addWeighted(-calcScoreConf.GoldEarnedRank[3], weight.Gold, lcu.ScoreOptionGoldEarnedRank)
+		}
+	}
+	// 伤害排名
+	if enabled(conf.MetricHurtRank) && totalHurt > 0 {
+		// 按位置修正时,伤害先除以各自位置的期望伤害占比再排名
+		hurtRank := 1
+		userHurt := norm.hurt(userParticipant)
+		for _, member := range ctx.members {
+			if norm.hurt(member) > userHurt {
+				hurtRank++
+			}
+		}
+		if hurtRank == 1 {
+			addWeighted(calcScoreConf.HurtRank[0], weight.Hurt, lcu.ScoreOptionHurtRank)
+		} else if hurtRank == 2 {
+			addWeighted(calcScoreConf.HurtRank[1], weight.Hurt, lcu.ScoreOptionHurtRank)
+		}
+	}
+	// 金钱转换伤害比
+	if enabled(conf.MetricMoney2HurtRate) && totalMoney > 0 && totalHurt > 0 {
+		money2hurtRateRank := 1
+		userMoney2hurtRate := float64(userParticipant.Stats.TotalDamageDealtToChampions) / float64(userParticipant.Stats.
+			GoldEarned)
+		memberMoney2hurtRateList := listMemberMoney2hurtRate(ctx.members)
+		for _, v := range memberMoney2hurtRateList {
+			if v > userMoney2hurtRate {
+				money2hurtRateRank++
+			}
+		}
+		if money2hurtRateRank == 1 {
+			addWeighted(calcScoreConf.Money2hurtRateRank[0], weight.Hurt, lcu.ScoreOptionMoney2hurtRateRank)
+		} else if money2hurtRateRank == 2 {
+			addWeighted(calcScoreConf.Money2hurtRateRank[1], weight.Hurt, lcu.ScoreOptionMoney2hurtRateRank)
+		}
+	}
+	// 视野得分
+	if enabled(conf.MetricVisionScore) {
+		visionScoreRank := 1
+		userVisionScore := userParticipant.Stats.VisionScore
+		memberVisionScoreList := listMemberVisionScore(ctx.members)
+		for _, v := range memberVisionScoreList {
+			if v > userVisionScore {
+				visionScoreRank++
+			}
+		}
+		if visionScoreRank == 1 {
+			addWeighted(calcScoreConf.VisionScoreRank[0], weight.Vision, lcu.ScoreOptionVisionScoreRank)
+		} else if visionScoreRank == 2 {
+			addWeighted(calcScoreConf.VisionScoreRank[1], weight.Vision, lcu.ScoreOptionVisionScoreRank)
+		}
+	}
+	// 补兵 每分钟8个刀以上加5分 ,9+10, 10+20
+	if gameDurationMinute := gameSummary.GameDuration / 60; enabled(conf.MetricMinionsKilled) && gameDurationMinute > 0 {
+		minuteMinionsKilled := norm.minionsPerMin(userParticipant, gameDurationMinute)
+		for _, minionsKilledLimit := range calcScoreConf.MinionsKilled {
+			if minuteMinionsKilled >= minionsKilledLimit[0] {
+				addWeighted(minionsKilledLimit[1], weight.Minions, lcu.ScoreOptionMinionsKilled)
+				break
+			}
+		}
+	}
+	// 人头占比
+	if enabled(conf.MetricKillRate) && totalKill > 0 {
+		// 人头占比>50%,limit 为百分比
+		userKillRate := norm.killRate(float64(userParticipant.Stats.Kills) / float64(totalKill) * 100)
+	userKillRateLoop:
+		for _, killRateConfItem := range calcScoreConf.KillRate {
+			if userKillRate > killRateConfItem.Limit {
+				for _, limitConf := range killRateConfItem.ScoreConf {
+					if userParticipant.Stats.Kills > int(limitConf[0]) {
+						addWeighted(limitConf[1], weight.Kill, lcu.ScoreOptionKillRate)
+						break userKillRateLoop
+					}
+				}
+			}
+		}
+	}
+	// 伤害占比
+	if enabled(conf.MetricHurtRate) && totalHurt > 0 {
+		// 伤害占比>40%,limit 为百分比
+		userHurtRate := norm.hurtRate(float64(userParticipant.Stats.TotalDamageDealtToChampions) / float64(totalHurt) * 100)
+	userHurtRateLoop:
+		for _, killRateConfItem := range calcScoreConf.HurtRate {
+			if userHurtRate > killRateConfItem.Limit {
+				for _, limitConf := range killRateConfItem.ScoreConf {
+					if userParticipant.Stats.Kills > int(limitConf[0]) {
+						addWeighted(limitConf[1], weight.Hurt, lcu.ScoreOptionHurtRate)
+						break userHurtRateLoop
+					}
+				}
+			}
+		}
+	}
+	// 助攻占比
+	if enabled(conf.MetricAssistRate) && totalAssist > 0 {
+		// 助攻占比>50%
+		userAssistRate := float64(userParticipant.Stats.Assists) / float64(totalAssist)
+	userAssistRateLoop:
+		for _, killRateConfItem := range calcScoreConf.AssistRate {
+			if userAssistRate > killRateConfItem.Limit {
+				for _, limitConf := range killRateConfItem.ScoreConf {
+					if userParticipant.Stats.Kills > int(limitConf[0]) {
+						gameScore.Add(limitConf[1], lcu.ScoreOptionAssistRate)
+						break userAssistRateLoop
+					}
+				}
+			}
+		}
+	}
+	userJoinTeamKillRate := 1.0
+	if totalKill > 0 {
+		userJoinTeamKillRate = float64(userParticipant.Stats.Assists+userParticipant.Stats.Kills) / float64(
+			totalKill)
+	}
+	userDeathTimes := userParticipant.Stats.Deaths
+	if userParticipant.Stats.Deaths == 0 {
+		userDeathTimes = 1
+	}
+	adjustVal := (float64(userParticipant.Stats.Kills+userParticipant.Stats.Assists)/float64(userDeathTimes) -
+		calcScoreConf.AdjustKDA[0] +
+		float64(userParticipant.Stats.Kills-userParticipant.Stats.Deaths)/calcScoreConf.AdjustKDA[1]) * userJoinTeamKillRate
+	if enabled(conf.MetricAdjustKDA) {
+		gameScore.Add(adjustVal, lcu.ScoreOptionKDAAdjust)
+	}
+	if enabled(conf.MetricFirstTower) {
+		stats := userParticipant.Stats
+		// 一塔
+		addFirstObjective(gameScore, stats.FirstTowerKill, stats.FirstTowerAssist, calcScoreConf.FirstTower,
+			lcu.ScoreOptionFirstTowerKill, lcu.ScoreOptionFirstTowerAssist)
+		// 首个水晶
+		addFirstObjective(gameScore, stats.FirstInhibitorKill, stats.FirstInhibitorAssist, calcScoreConf.FirstInhibitor,
+			lcu.ScoreOptionFirstInhibitorKill, lcu.ScoreOptionFirstInhibitorAssist)
+	}
+	// 战略点伤害占比,门槛为战略点伤害(千)
+	if enabled(conf.MetricObjectiveRate) && ctx.totalObjective > 0 {
+		objectiveDamage := userParticipant.Stats.DamageDealtToObjectives
+		if incVal, ok := rateItemScore(float64(objectiveDamage)/float64(ctx.totalObjective),
+			float64(objectiveDamage)/1000, calcScoreConf.ObjectiveRate); ok {
+			gameScore.Add(incVal, lcu.ScoreOptionObjectiveRate)
+		}
+	}
+	// 推塔占比,门槛为推塔数
+	if enabled(conf.MetricTowerRate) && ctx.totalTowers > 0 {
+		turretKills := userParticipant.Stats.TurretKills
+		if incVal, ok := rateItemScore(float64(turretKills)/float64(ctx.totalTowers), float64(turretKills),
+			calcScoreConf.TowerRate); ok {
+			gameScore.Add(incVal, lcu.ScoreOptionTowerRate)
+		}
+	}
+	// 对线得分,配置计入总分时才加分
+	if calcScoreConf.Laning.FoldIntoTotal && enabled(conf.MetricLaning) {
+		if laning, ok := Laning(gameSummary, puuid, calcScoreConf.Laning); ok {
+			gameScore.Add(laning, lcu.ScoreOptionLaning)
+		}
+	}
+	return gameScore, nil
+}
+
+// addFirstObjective 一塔/首个水晶的击杀或助攻加分,加分为0时不记录
+func addFirstObjective(gameScore *lcu.ScoreWithReason, kill bool, assist bool, incVal [2]float64,
+	killOption lcu.ScoreOption, assistOption lcu.ScoreOption) {
+	if kill && incVal[0] != 0 {
+		gameScore.Add(incVal[0], killOption)
+	} else if !kill && assist && incVal[1] != 0 {
+		gameScore.Add(incVal[1], assistOption)
+	}
+}
+
+// rateItemScore 占比超过 limit% 且数值达到门槛时的加分,按配置顺序取第一个满足的
+func rateItemScore(rate float64, val float64, items []conf.RateItemConf) (float64, bool) {
+	for _, item := range items {
+		if rate*100 <= item.Limit {
+			continue
+		}
+		for _, limitConf := range item.ScoreConf {
+			if val >= limitConf[0] {
+				return limitConf[1], true
+			}
+		}
+	}
+	return 0, false
+}
+
+func listMemberVisionScore(members []lcu.Participant) []int {
+	res := make([]int, 0, 4)
+	for _, participant := range members {
+		res = append(res, participant.Stats.VisionScore)
+	}
+	return res
+}
+
+func listMemberMoney2hurtRate(members []lcu.Participant) []float64 {
+	res := make([]float64, 0, 4)
+	for _, participant := range members {
+		res = append(res, float64(participant.Stats.TotalDamageDealtToChampions)/float64(participant.Stats.
+			GoldEarned))
+	}
+	return res
+}
+
+func listMemberMoney(members []lcu.Participant) []int {
+	res := make([]int, 0, 4)
+	for _, participant := range members {
+		res = append(res, participant.Stats.GoldEarned)
+	}
+	return res
+}
+
+func listMemberJoinTeamKillRates(members []lcu.Participant, totalKill int) []float64 {
+	res := make([]float64, 0, 4)
+	for _, participant := range members {
+		res = append(res, float64(participant.Stats.Assists+participant.Stats.Kills)/float64(
+			totalKill))
+	}
+	return res
+}
+
+// roleNormalizer 按位置修正玩家数据,未开启或无法识别位置时不修正
+type roleNormalizer struct {
+	conf *conf.RoleNormalizeConf
+	ctx  *gameContext
+	role *conf.RoleScoreConf // 玩家所在位置
+	ref  *conf.RoleScoreConf // 基准位置
+}
+
+func (s *DefaultScorer) newRoleNormalizer(ctx *gameContext) *roleNormalizer {
+	n := &roleNormalizer{conf: &s.conf.RoleNormalize, ctx: ctx}
+	if !n.conf.Enabled {
+		return n
+	}
+	ref, ok := n.conf.Roles[conf.RoleNormalizeReference]
+	if !ok {
+		return n
+	}
+	n.ref = &ref
+	n.role = n.roleOf(ctx.user)
+	return n
+}
+
+func (n *roleNormalizer) roleOf(participant lcu.Participant) *conf.RoleScoreConf {
+	if n.ref == nil {
+		return nil
+	}
+	role, ok := n.conf.Roles[string(n.ctx.positions[participant.ParticipantId])]
+	if !ok {
+		return nil
+	}
+	return &role
+}
+
+func (n *roleNormalizer) enabled() bool {
+	return n.role != nil
+}
+
+func (n *roleNormalizer) weight() conf.RoleWeightConf {
+	if !n.enabled() {
+		return conf.RoleWeightConf{Minions: 1, Hurt: 1, Kill: 1, Gold: 1, Vision: 1}
+	}
+	return n.role.Weight
+}
+
+// minionsPerMin 分均补兵,修正时包含野怪
+func (n *roleNormalizer) minionsPerMin(participant lcu.Participant, gameDurationMinute int) float64 {
+	if !n.enabled() {
+		return float64(participant.Stats.TotalMinionsKilled / gameDurationMinute)
+	}
+	minions := participant.Stats.TotalMinionsKilled + participant.Stats.NeutralMinionsKilled
+	return float64(minions) / float64(gameDurationMinute) * n.ref.MinionsPerMin / n.role.MinionsPerMin
+}
+
+func (n *roleNormalizer) killRate(rate float64) float64 {
+	if !n.enabled() {
+		return rate
+	}
+	return rate * n.ref.KillRate / n.role.KillRate
+}
+
+func (n *roleNormalizer) hurtRate(rate float64) float64 {
+	if !n.enabled() {
+		return rate
+	}
+	return rate * n.ref.HurtRate / n.role.HurtRate
+}
+
+// hurt 队友之间比较的伤害,按各自位置的期望伤害占比修正
+func (n *roleNormalizer) hurt(participant lcu.Participant) float64 {
+	hurt := float64(participant.Stats.TotalDamageDealtToChampions)
+	if !n.enabled() {
+		return hurt
+	}
+	if role := n.roleOf(participant); role != nil {
+		return hurt * n.ref.HurtRate / role.HurtRate
+	}
+	return hurt
+}
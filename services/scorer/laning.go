@@ -0,0 +1,104 @@
+package scorer
+
+import (
+	"math"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+// 对线阶段,[0-10分钟,10-20分钟]结束时的游戏时长
+var laningPhaseEnds = [2]int{10 * 60, 20 * 60}
+
+// Laning 玩家在一局中的对线得分,根据与对位玩家前20分钟的每分钟补刀差/经验差/金钱差计算,0为均势
+// 无法识别位置、找不到对位玩家或没有timeline数据时返回false
+func Laning(gameSummary *lcu.GameSummary, puuid string, cfg conf.LaningConf) (float64, bool) {
+	if !cfg.Enabled {
+		return 0, false
+	}
+	user := gameSummary.FindParticipant(puuid)
+	if user == nil || !hasLaningDeltas(*user) {
+		return 0, false
+	}
+	opponent := laneOpponent(gameSummary, *user)
+	if opponent == nil || !hasLaningDeltas(*opponent) {
+		return 0, false
+	}
+	userDeltas, opponentDeltas := laningDeltas(*user), laningDeltas(*opponent)
+	score, totalWeight := 0.0, 0.0
+	for i, end := range laningPhaseEnds {
+		// 游戏时长不足的阶段没有数据
+		if gameSummary.GameDuration < end || cfg.PhaseWeight[i] == 0 {
+			continue
+		}
+		csDiff := userDeltas[0][i] - opponentDeltas[0][i]
+		xpDiff := userDeltas[1][i] - opponentDeltas[1][i]
+		goldDiff := userDeltas[2][i] - opponentDeltas[2][i]
+		score += cfg.PhaseWeight[i] * (csDiff*cfg.CsDiff + xpDiff/100*cfg.XpDiff + goldDiff/100*cfg.GoldDiff)
+		totalWeight += cfg.PhaseWeight[i]
+	}
+	if totalWeight == 0 {
+		return 0, false
+	}
+	return math.Max(-cfg.Max, math.Min(cfg.Max, score/totalWeight)), true
+}
+
+// AverageLaning 各局对线得分按对局权重的平均值,没有对线数据时返回false
+func AverageLaning(gameScoreList []lcu.GameScore) (float64, bool) {
+	total, totalWeight := 0.0, 0.0
+	for _, game := range gameScoreList {
+		if game.Laning == nil || game.Weight <= 0 {
+			continue
+		}
+		total += *game.Laning * game.Weight
+		totalWeight += game.Weight
+	}
+	if totalWeight == 0 {
+		return 0, false
+	}
+	return total / totalWeight, true
+}
+
+// laneOpponent 敌方队伍中与玩家位置相同的玩家
+func laneOpponent(gameSummary *lcu.GameSummary, user lcu.Participant) *lcu.Participant {
+	teams := make(map[models.TeamID][]lcu.Participant, 2)
+	for _, participant := range gameSummary.Participants {
+		teams[participant.TeamId] = append(teams[participant.TeamId], participant)
+	}
+	position := detectPositions(teams[user.TeamId])[user.ParticipantId]
+	if position == models.PositionUnknown {
+		return nil
+	}
+	for teamID, members := range teams {
+		if teamID == user.TeamId {
+			continue
+		}
+		for id, p := range detectPositions(members) {
+			if p != position {
+				continue
+			}
+			for i := range members {
+				if members[i].ParticipantId == id {
+					return &members[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// laningDeltas [补刀,经验,金钱][阶段] 每分钟数据
+func laningDeltas(participant lcu.Participant) [3][2]float64 {
+	timeline := participant.Timeline
+	return [3][2]float64{
+		{timeline.CreepsPerMinDeltas.Field1, timeline.CreepsPerMinDeltas.Field2},
+		{timeline.XpPerMinDeltas.Field1, timeline.XpPerMinDeltas.Field2},
+		{timeline.GoldPerMinDeltas.Field1, timeline.GoldPerMinDeltas.Field2},
+	}
+}
+
+func hasLaningDeltas(participant lcu.Participant) bool {
+	timeline := participant.Timeline
+	return timeline.XpPerMinDeltas.Field1 > 0 || timeline.GoldPerMinDeltas.Field1 > 0
+}
@@ -0,0 +1,62 @@
+package scorer
+
+import (
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+// DetectPosition 玩家在对局中的位置,无法识别时返回 models.PositionUnknown
+func DetectPosition(gameSummary *lcu.GameSummary, puuid string) models.Position {
+	user := gameSummary.FindParticipant(puuid)
+	if user == nil {
+		return models.PositionUnknown
+	}
+	members := make([]lcu.Participant, 0, 5)
+	for _, participant := range gameSummary.Participants {
+		if participant.TeamId == user.TeamId {
+			members = append(members, participant)
+		}
+	}
+	return detectPositions(members)[user.ParticipantId]
+}
+
+// detectPositions 根据timeline中的lane和role识别队伍中每个人的位置,key为participantId
+func detectPositions(members []lcu.Participant) map[int]models.Position {
+	positions := make(map[int]models.Position, len(members))
+	bottoms := make([]lcu.Participant, 0, 2)
+	for _, participant := range members {
+		id := participant.ParticipantId
+		if models.Spell(participant.Spell1Id) == models.SpellChengJie ||
+			models.Spell(participant.Spell2Id) == models.SpellChengJie {
+			positions[id] = models.PositionJungle
+			continue
+		}
+		switch participant.Timeline.Lane {
+		case models.LaneTop:
+			positions[id] = models.PositionTop
+		case models.LaneJungle:
+			positions[id] = models.PositionJungle
+		case models.LaneMiddle, models.LaneMid:
+			positions[id] = models.PositionMid
+		case models.LaneBottom, models.LaneBot:
+			switch participant.Timeline.Role {
+			case models.ChampionRoleSupport:
+				positions[id] = models.PositionSupport
+			case models.ChampionRoleADC:
+				positions[id] = models.PositionADC
+			default:
+				bottoms = append(bottoms, participant)
+			}
+		}
+	}
+	// 双人路没有区分adc和辅助时,补兵少的为辅助
+	if len(bottoms) == 2 {
+		adc, support := bottoms[0], bottoms[1]
+		if adc.Stats.TotalMinionsKilled < support.Stats.TotalMinionsKilled {
+			adc, support = support, adc
+		}
+		positions[adc.ParticipantId] = models.PositionADC
+		positions[support.ParticipantId] = models.PositionSupport
+	}
+	return positions
+}
@@ -0,0 +1,109 @@
+package scorer
+
+import (
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/pkg/expr"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+// RuleScorer 配置中的规则评分模型,每条成立的规则在默认分数上加分
+type RuleScorer struct {
+	name  string
+	rules []*expr.Rule
+}
+
+func NewRuleScorer(model conf.ScoreModelConf) (*RuleScorer, error) {
+	s := &RuleScorer{
+		name:  model.Name,
+		rules: make([]*expr.Rule, 0, len(model.Rules)),
+	}
+	for _, src := range model.Rules {
+		rule, err := expr.ParseRule(src, conf.ScoreRuleVars)
+		if err != nil {
+			return nil, err
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return s, nil
+}
+
+func (s *RuleScorer) Name() string {
+	return s.name
+}
+
+func (s *RuleScorer) Score(puuid string, gameSummary *lcu.GameSummary) (*lcu.ScoreWithReason, error) {
+	ctx, err := newGameContext(puuid, gameSummary)
+	if err != nil {
+		return nil, err
+	}
+	env := ruleEnv(ctx, gameSummary)
+	gameScore := lcu.NewScoreWithReason(DefaultScore)
+	for _, rule := range s.rules {
+		if incVal, ok := rule.Apply(env); ok {
+			gameScore.Add(incVal, lcu.ScoreOption(rule.Src))
+		}
+	}
+	return gameScore, nil
+}
+
+// ruleEnv 规则变量取值,变量列表见 conf.ScoreRuleVars
+func ruleEnv(ctx *gameContext, gameSummary *lcu.GameSummary) expr.Env {
+	stats := ctx.user.Stats
+	gameMinutes := float64(gameSummary.GameDuration) / 60
+	minionsPerMin := 0.0
+	if gameMinutes > 0 {
+		minionsPerMin = float64(stats.TotalMinionsKilled) / gameMinutes
+	}
+	deaths := stats.Deaths
+	if deaths == 0 {
+		deaths = 1
+	}
+	return expr.Env{
+		"kills":                float64(stats.Kills),
+		"deaths":               float64(stats.Deaths),
+		"assists":              float64(stats.Assists),
+		"kda":                  float64(stats.Kills+stats.Assists) / float64(deaths),
+		"damage":               float64(stats.TotalDamageDealtToChampions),
+		"damageTaken":          float64(stats.TotalDamageTaken),
+		"gold":                 float64(stats.GoldEarned),
+		"visionScore":          float64(stats.VisionScore),
+		"minions":              float64(stats.TotalMinionsKilled),
+		"minionsPerMin":        minionsPerMin,
+		"doubleKills":          float64(stats.DoubleKills),
+		"tripleKills":          float64(stats.TripleKills),
+		"quadraKills":          float64(stats.QuadraKills),
+		"pentaKills":           float64(stats.PentaKills),
+		"firstBloodKill":       boolVal(stats.FirstBloodKill),
+		"firstBloodAssist":     boolVal(stats.FirstBloodAssist),
+		"win":                  boolVal(stats.Win),
+		"gameMinutes":          gameMinutes,
+		"teamKills":            float64(ctx.totalKill),
+		"teamDeaths":           float64(ctx.totalDeath),
+		"teamAssists":          float64(ctx.totalAssist),
+		"teamDamage":           float64(ctx.totalHurt),
+		"teamGold":             float64(ctx.totalMoney),
+		"isTop":                boolVal(ctx.position() == models.PositionTop),
+		"isJungle":             boolVal(ctx.position() == models.PositionJungle),
+		"isMid":                boolVal(ctx.position() == models.PositionMid),
+		"isAdc":                boolVal(ctx.position() == models.PositionADC),
+		"isSupport":            boolVal(ctx.position() == models.PositionSupport),
+		"objectiveDamage":      float64(stats.DamageDealtToObjectives),
+		"turretDamage":         float64(stats.DamageDealtToTurrets),
+		"turretKills":          float64(stats.TurretKills),
+		"inhibitorKills":       float64(stats.InhibitorKills),
+		"firstTowerKill":       boolVal(stats.FirstTowerKill),
+		"firstTowerAssist":     boolVal(stats.FirstTowerAssist),
+		"firstInhibitorKill":   boolVal(stats.FirstInhibitorKill),
+		"firstInhibitorAssist": boolVal(stats.FirstInhibitorAssist),
+		"teamObjectiveDamage":  float64(ctx.totalObjective),
+		"teamTowerKills":       float64(ctx.totalTowers),
+	}
+}
+
+func boolVal(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package scorer
+
+import (
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultScore = 100 // 默认分数
+)
+
+type (
+	// Scorer 根据一局对局详情计算玩家得分
+	Scorer interface {
+		Name() string
+		Score(puuid string, gameSummary *lcu.GameSummary) (*lcu.ScoreWithReason, error)
+	}
+	// gameContext 玩家在一局中的数据以及所在队伍的合计
+	gameContext struct {
+		user        lcu.Participant
+		members     []lcu.Participant       // 包含玩家自己
+		positions   map[int]models.Position // 队伍中每个人的位置,key为participantId
+		totalKill   int                     // 总人头
+		totalDeath  int                     // 总死亡
+		totalAssist int                     // 总助攻
+		totalHurt   int                     // 总伤害
+		totalMoney  int                     // 总金钱
+		// totalObjective 总战略点伤害, totalTowers 推掉的防御塔数
+		totalObjective int
+		totalTowers    int
+	}
+)
+
+// New 返回配置中当前使用的评分算法
+func New(cfg *conf.CalcScoreConf) Scorer {
+	for _, model := range cfg.ScoreModels {
+		if model.Name == cfg.Scorer {
+			s, err := NewRuleScorer(model)
+			if err != nil {
+				break
+			}
+			return s
+		}
+	}
+	return NewDefaultScorer(cfg)
+}
+
+// All 返回默认算法以及所有自定义模型,用于对比
+func All(cfg *conf.CalcScoreConf) []Scorer {
+	list := []Scorer{NewDefaultScorer(cfg)}
+	for _, model := range cfg.ScoreModels {
+		s, err := NewRuleScorer(model)
+		if err != nil {
+			continue
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+func newGameContext(puuid string, gameSummary *lcu.GameSummary) (*gameContext, error) {
+	user := gameSummary.FindParticipant(puuid)
+	if user == nil {
+		return nil, errors.New("获取用户位置失败")
+	}
+	ctx := &gameContext{
+		user:    *user,
+		members: make([]lcu.Participant, 0, 5),
+	}
+	for _, participant := range gameSummary.Participants {
+		if participant.TeamId != user.TeamId {
+			continue
+		}
+		ctx.members = append(ctx.members, participant)
+		ctx.totalKill += participant.Stats.Kills
+		ctx.totalDeath += participant.Stats.Deaths
+		ctx.totalAssist += participant.Stats.Assists
+		ctx.totalHurt += participant.Stats.TotalDamageDealtToChampions
+		ctx.totalMoney += participant.Stats.GoldEarned
+		ctx.totalObjective += participant.Stats.DamageDealtToObjectives
+		ctx.totalTowers += participant.Stats.TurretKills
+	}
+	// 队伍数据中的推塔数包含小兵推掉的塔
+	for _, team := range gameSummary.Teams {
+		if models.TeamID(team.TeamId) == user.TeamId && team.TowerKills > ctx.totalTowers {
+			ctx.totalTowers = team.TowerKills
+		}
+	}
+	ctx.positions = detectPositions(ctx.members)
+	return ctx, nil
+}
+
+// position 玩家自己的位置
+func (ctx *gameContext) position() models.Position {
+	return ctx.positions[ctx.user.ParticipantId]
+}
@@ -0,0 +1,533 @@
+package scorer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/global"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/lcutest"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+func TestScorers(t *testing.T) {
+	game := lcutest.NewGame(1, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10).Summary()
+	cfg := global.DefaultAppConf.CalcScore
+	cfg.ScoreModels = []conf.ScoreModelConf{{
+		Name: "carry",
+		Rules: []string{
+			"kills/teamKills >= 0.2 && win => +20",
+			"deaths > 3 => -5",
+			"minionsPerMin > 8 => 10",
+		},
+	}}
+
+	// 数据相同的队友各项排名都是第一
+	score, err := NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	if err != nil || math.Abs(score.Value()-150) > 1e-9 {
+		t.Fatalf("default score = %v, %v; want 150", score, err)
+	}
+	if _, err = NewDefaultScorer(&cfg).Score("puuid-404", &game); err == nil {
+		t.Error("不在对局中的玩家应返回错误")
+	}
+
+	if New(&cfg).Name() != conf.DefaultScorerName {
+		t.Errorf("未指定模型时应使用默认算法")
+	}
+	cfg.Scorer = "carry"
+	s := New(&cfg)
+	if s.Name() != "carry" {
+		t.Fatalf("scorer = %s, want carry", s.Name())
+	}
+	for puuid, want := range map[string]float64{"puuid-1": 115, "puuid-6": 95} {
+		score, err = s.Score(puuid, &game)
+		if err != nil || score.Value() != want {
+			t.Errorf("%s rule score = %v, %v; want %v", puuid, score, err, want)
+		}
+	}
+	if all := All(&cfg); len(all) != 2 || all[0].Name() != conf.DefaultScorerName {
+		t.Errorf("All() = %v", all)
+	}
+}
+
+func TestRoleNormalize(t *testing.T) {
+	g := lcutest.NewGame(1, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	lanes := []struct {
+		lane    models.Lane
+		role    models.ChampionRole
+		minions int
+	}{
+		{models.LaneTop, models.ChampionRoleSolo, 180},
+		{models.LaneJungle, models.ChampionRoleNone, 40},
+		{models.LaneMiddle, models.ChampionRoleSolo, 200},
+		{models.LaneBottom, models.ChampionRoleDuo, 220},
+		{models.LaneBottom, models.ChampionRoleDuo, 30},
+	}
+	for i, lane := range lanes {
+		g.Players[i].Lane, g.Players[i].Role, g.Players[i].Minions = lane.lane, lane.role, lane.minions
+	}
+	// 辅助打钱最少,视野最高,人头占比23%、伤害占比20%,按位置修正后超过默认门槛
+	g.Players[4].Gold = 6000
+	g.Players[4].VisionScore = 80
+	g.Players[4].Kills = 6
+	game := g.Summary()
+
+	want := []models.Position{models.PositionTop, models.PositionJungle, models.PositionMid, models.PositionADC,
+		models.PositionSupport}
+	for i, position := range want {
+		if got := DetectPosition(&game, g.Players[i].Puuid); got != position {
+			t.Errorf("player %d position = %q, want %q", i+1, got, position)
+		}
+	}
+
+	cfg := global.DefaultAppConf.CalcScore
+	cfg.RoleNormalize.Enabled = true
+	reasons := func(cfg conf.CalcScoreConf) map[lcu.ScoreOption]float64 {
+		score, err := NewDefaultScorer(&cfg).Score("puuid-5", &game)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := map[lcu.ScoreOption]float64{}
+		for _, reason := range score.Reasons() {
+			res[reason.Reason] += reason.IncVal
+		}
+		return res
+	}
+	normalized := reasons(cfg)
+	if normalized[lcu.ScoreOptionVisionScoreRank] != 15 {
+		t.Errorf("辅助视野权重1.5, got %v", normalized)
+	}
+	if _, ok := normalized[lcu.ScoreOptionGoldEarnedRank]; ok {
+		t.Errorf("辅助不计算打钱排名, got %v", normalized)
+	}
+	if normalized[lcu.ScoreOptionKillRate] != 10 || normalized[lcu.ScoreOptionHurtRate] != 10 {
+		t.Errorf("辅助修正后的人头和伤害占比应加分, got %v", normalized)
+	}
+	cfg.RoleNormalize.Enabled = false
+	plain := reasons(cfg)
+	if plain[lcu.ScoreOptionVisionScoreRank] != 10 {
+		t.Errorf("未修正时视野为10, got %v", plain)
+	}
+	if _, ok := plain[lcu.ScoreOptionKillRate]; ok {
+		t.Errorf("未修正时人头占比不到门槛, got %v", plain)
+	}
+	if _, ok := plain[lcu.ScoreOptionHurtRate]; ok {
+		t.Errorf("未修正时伤害占比不到门槛, got %v", plain)
+	}
+}
+
+func TestModeDisabledMetrics(t *testing.T) {
+	g := lcutest.NewGame(1, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	g.QueueID, g.Mode = models.ARAMQueueID, models.GameModeARAM
+	g.Players[0].Minions = 400
+	game := g.Summary()
+	cfg := global.DefaultAppConf.CalcScore
+	score, err := NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, reason := range score.Reasons() {
+		if reason.Reason == lcu.ScoreOptionVisionScoreRank || reason.Reason == lcu.ScoreOptionMinionsKilled {
+			t.Errorf("大乱斗不应计算 %s", reason.Reason)
+		}
+	}
+	if mode := cfg.ModeOf(int(models.URFQueueID), ""); mode == nil || mode.Name != "urf" {
+		t.Errorf("无限火力队列应匹配urf模式, got %v", mode)
+	}
+	if mode := cfg.ModeOf(0, string(models.GameModeARAM)); mode == nil || mode.Name != "aram" {
+		t.Errorf("未知队列应按游戏模式匹配, got %v", mode)
+	}
+}
+
+func TestWeigh(t *testing.T) {
+	now := time.Now()
+	games := func() []lcu.GameScore {
+		return []lcu.GameScore{
+			{GameCreation: now.Add(-time.Hour), Score: 140},
+			{GameCreation: now.Add(-time.Hour * 2), Score: 120},
+			{GameCreation: now.Add(-time.Hour * 50), Score: 80},
+		}
+	}
+	cfg := global.DefaultAppConf.CalcScore.Weighting
+	cfg.Model = conf.WeightingStep
+	// 与原来的算法一致: 0.8*近期平均 + 0.2*其余平均
+	list := games()
+	res := Weigh(list, cfg, now)
+	if math.Abs(res.Score-(0.8*130+0.2*80)) > 1e-9 || math.Abs(list[0].Weight-0.4) > 1e-9 {
+		t.Errorf("step = %+v, weights %v", res, list)
+	}
+
+	cfg.Model = conf.WeightingDecay
+	cfg.HalfLifeHours = 1
+	list = games()
+	res = Weigh(list, cfg, now)
+	if math.Abs(list[0].Weight-2*list[1].Weight) > 1e-9 || res.Score <= 130 || res.Score >= 140 {
+		t.Errorf("decay = %+v, weights %v", res, list)
+	}
+
+	cfg.Model = conf.WeightingRank
+	cfg.RankDecay = 1
+	res = Weigh(games(), cfg, now)
+	if math.Abs(res.Score-340.0/3) > 1e-6 || math.Abs(res.EffectiveGames-3) > 1e-9 ||
+		math.Abs(res.Confidence-3/(3+cfg.ConfidenceGames)) > 1e-9 {
+		t.Errorf("rank = %+v", res)
+	}
+
+	if res = Weigh(nil, cfg, now); res.Score != DefaultScore || res.Confidence != 0 {
+		t.Errorf("没有对局时应为默认分数, got %+v", res)
+	}
+}
+
+func TestLaning(t *testing.T) {
+	g := lcutest.NewGame(1, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	for _, i := range []int{0, 5} {
+		g.Players[i].Lane = models.LaneTop
+	}
+	g.Players[0].CsPerMin, g.Players[0].XpPerMin, g.Players[0].GoldPerMin = [2]float64{8, 9}, [2]float64{500, 600},
+		[2]float64{400, 450}
+	g.Players[5].CsPerMin, g.Players[5].XpPerMin, g.Players[5].GoldPerMin = [2]float64{6, 7}, [2]float64{450, 500},
+		[2]float64{350, 400}
+	game := g.Summary()
+	cfg := global.DefaultAppConf.CalcScore
+
+	// 0-10分钟 2+0.5+0.5=3, 10-20分钟 2+1+0.5=3.5
+	for puuid, want := range map[string]float64{"puuid-1": 3.2, "puuid-6": -3.2} {
+		if got, ok := Laning(&game, puuid, cfg.Laning); !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s laning = %v, %v; want %v", puuid, got, ok, want)
+		}
+	}
+	if _, ok := Laning(&game, "puuid-2", cfg.Laning); ok {
+		t.Error("没有timeline数据时不应计算对线得分")
+	}
+	short := game
+	short.GameDuration = 15 * 60
+	if got, _ := Laning(&short, "puuid-1", cfg.Laning); math.Abs(got-3) > 1e-9 {
+		t.Errorf("不足20分钟只计算0-10分钟, got %v", got)
+	}
+	capped := cfg.Laning
+	capped.Max = 1
+	if got, _ := Laning(&game, "puuid-1", capped); got != 1 {
+		t.Errorf("对线得分应限制在max内, got %v", got)
+	}
+
+	plain, err := NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Laning.FoldIntoTotal = true
+	folded, err := NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	if err != nil || math.Abs(folded.Value()-plain.Value()-3.2) > 1e-9 {
+		t.Errorf("计入总分后 = %v, %v; want %v", folded, err, plain.Value()+3.2)
+	}
+
+	avg, ok := AverageLaning([]lcu.GameScore{{Weight: 0.75, Laning: &[]float64{4}[0]}, {Weight: 0.25}})
+	if !ok || avg != 4 {
+		t.Errorf("没有对线数据的对局不参与平均, got %v", avg)
+	}
+}
+
+func TestObjectiveMetrics(t *testing.T) {
+	g := lcutest.NewGame(1, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	// 带线玩家推掉了队伍4座塔中的3座
+	g.Players[0].ObjectiveDamage, g.Players[0].TurretKills, g.Players[0].FirstTowerKill = 25000, 3, true
+	g.Players[1].ObjectiveDamage, g.Players[1].TurretKills = 15000, 1
+	game := g.Summary()
+	cfg := global.DefaultAppConf.CalcScore
+	// 默认不加分
+	score, err := NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, reason := range score.Reasons() {
+		if reason.Reason == lcu.ScoreOptionObjectiveRate || reason.Reason == lcu.ScoreOptionTowerRate ||
+			reason.Reason == lcu.ScoreOptionFirstTowerKill {
+			t.Errorf("默认不应计算 %s", reason.Reason)
+		}
+	}
+	cfg.ObjectiveRate = []conf.RateItemConf{
+		{Limit: 40, ScoreConf: [][2]float64{{20, 15}, {10, 10}}},
+		{Limit: 25, ScoreConf: [][2]float64{{20, 10}, {10, 5}}},
+	}
+	cfg.TowerRate = []conf.RateItemConf{
+		{Limit: 50, ScoreConf: [][2]float64{{3, 10}, {1, 5}}},
+		{Limit: 30, ScoreConf: [][2]float64{{2, 5}, {1, 3}}},
+	}
+	cfg.FirstTower, cfg.FirstInhibitor = [2]float64{5, 3}, [2]float64{5, 3}
+	score, err = NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := map[lcu.ScoreOption]float64{}
+	for _, reason := range score.Reasons() {
+		reasons[reason.Reason] += reason.IncVal
+	}
+	want := map[lcu.ScoreOption]float64{
+		lcu.ScoreOptionObjectiveRate:  15,
+		lcu.ScoreOptionTowerRate:      10,
+		lcu.ScoreOptionFirstTowerKill: 5,
+	}
+	for option, val := range want {
+		if reasons[option] != val {
+			t.Errorf("%s = %v, want %v", option, reasons[option], val)
+		}
+	}
+
+	cfg.Modes = append([]conf.ModeScoreConf(nil), cfg.Modes...)
+	cfg.Modes[0].DisabledMetrics = []string{conf.MetricObjectiveRate, conf.MetricTowerRate, conf.MetricFirstTower}
+	score, err = NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, reason := range score.Reasons() {
+		if _, ok := want[reason.Reason]; ok {
+			t.Errorf("关闭后不应计算 %s", reason.Reason)
+		}
+	}
+}
+
+func TestBehaviors(t *testing.T) {
+	g := lcutest.NewGame(1, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	g.Players[0].Damage, g.Players[0].Gold = 200, 1500
+	g.Players[1].CausedEarlySurrender = true
+	g.Players[2].Kills, g.Players[2].Deaths, g.Players[2].Assists = 1, 14, 3
+	game := g.Summary()
+	cfg := global.DefaultAppConf.CalcScore.Behavior
+	want := map[string][]lcu.Behavior{
+		"puuid-1": {lcu.BehaviorLeaver},
+		"puuid-2": {lcu.BehaviorSurrender},
+		"puuid-3": {lcu.BehaviorInter},
+		"puuid-4": nil,
+	}
+	for puuid, behaviors := range want {
+		if got := GameBehaviors(&game, puuid, cfg); len(got) != len(behaviors) || len(got) > 0 && got[0] != behaviors[0] {
+			t.Errorf("%s behaviors = %v, want %v", puuid, got, behaviors)
+		}
+	}
+
+	g2 := lcutest.NewGame(2, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	g2.Players[2].Damage, g2.Players[2].Gold = 200, 1500
+	g2.Players[2].Kills, g2.Players[2].Deaths, g2.Players[2].Assists = 1, 14, 3
+	list := []lcu.GameSummary{game, g2.Summary(), lcutest.NewGame(3, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10).Summary()}
+	badges := Badges(list, "puuid-3", cfg)
+	if len(badges) != 1 || badges[0] != (lcu.Badge{Behavior: lcu.BehaviorInter, Games: 2}) {
+		t.Errorf("只出现1次的行为不应标记, got %v", badges)
+	}
+}
+
+func TestCalcStreak(t *testing.T) {
+	now := time.Now()
+	game := func(id int64, ago time.Duration, win bool) lcu.GameInfo {
+		g := lcutest.NewGame(id, now.Add(-ago), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+		g.Players[0].Win = win
+		return g.InfoFor("puuid-1")
+	}
+	remake := game(1, time.Minute*5, true)
+	remake.GameDuration = 120
+	history := []lcu.GameInfo{
+		game(6, time.Hour*11, true),
+		remake,
+		game(2, time.Minute*40, false),
+		game(3, time.Minute*80, false),
+		game(4, time.Minute*119, false),
+		game(5, time.Hour*10, true),
+	}
+	cfg := global.DefaultAppConf.CalcScore.Streak
+	streak := CalcStreak(history, cfg, now)
+	want := lcu.Streak{Losses: 3, RecentGames: 5, RecentWins: 2, SessionGames: 3}
+	if streak == nil || *streak != want {
+		t.Fatalf("streak = %+v, want %+v", streak, want)
+	}
+	cfg.TiltLosses = 3
+	if streak = CalcStreak(history, cfg, now); !streak.Tilt {
+		t.Errorf("2小时内输了3局应标记上头, got %+v", streak)
+	}
+	cfg.Enabled = false
+	if streak = CalcStreak(history, cfg, now); streak != nil {
+		t.Errorf("未开启时应为空, got %+v", streak)
+	}
+}
+
+func TestCheckAccount(t *testing.T) {
+	history := func(n int, edit func(i int, p *lcutest.Player)) []lcu.GameInfo {
+		list := make([]lcu.GameInfo, 0, n)
+		for i := 0; i < n; i++ {
+			g := lcutest.NewGame(int64(i+1), time.Now().Add(-time.Hour*time.Duration(i)), 1, 2, 3, 4, 5, 6, 7, 8, 9,
+				10)
+			edit(i, &g.Players[0])
+			list = append(list, g.InfoFor("puuid-1"))
+		}
+		return list
+	}
+	cfg := global.DefaultAppConf.CalcScore.Smurf
+
+	// 低等级,对局少,全胜,同一个英雄,kda很高
+	smurf := CheckAccount(20, history(6, func(i int, p *lcutest.Player) {
+		p.Kills, p.Deaths, p.Assists = 10, 1, 5
+	}), 20, cfg)
+	if smurf.Smurf != 1 || len(smurf.SmurfReasons) != 5 || smurf.Boosted != 0 {
+		t.Errorf("smurf = %+v", smurf)
+	}
+	// 查询的战绩数量太少时不能判断账号对局少
+	res := CheckAccount(20, history(6, func(i int, p *lcutest.Player) {}), 6, cfg)
+	for _, reason := range res.SmurfReasons {
+		if strings.Contains(reason, "战绩") {
+			t.Errorf("不应命中对局少的信号, got %+v", res)
+		}
+	}
+
+	// 高等级,全胜但kda很低,每局换英雄
+	boosted := CheckAccount(300, history(20, func(i int, p *lcutest.Player) {
+		p.Kills, p.Deaths, p.Assists, p.ChampionID = 1, 5, 2, i+1
+	}), 20, cfg)
+	if math.Abs(boosted.Boosted-0.75) > 1e-9 || math.Abs(boosted.Smurf-0.25) > 1e-9 {
+		t.Errorf("boosted = %+v", boosted)
+	}
+
+	cfg.Enabled = false
+	if res := CheckAccount(20, nil, 20, cfg); res != nil {
+		t.Errorf("未开启时应为空, got %+v", res)
+	}
+}
+
+func TestCalibrator(t *testing.T) {
+	cfg := global.DefaultAppConf.CalcScore
+	calibrator := NewCalibrator(&cfg, 3)
+	for i := 0; i < 3; i++ {
+		game := lcutest.NewGame(int64(i+1), time.Now().Add(-time.Hour*time.Duration(i)), 1, 2, 3, 4, 5, 6, 7, 8, 9,
+			10).Summary()
+		calibrator.Add(&game)
+	}
+	aram := lcutest.NewGame(100, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	aram.QueueID, aram.Mode = models.ARAMQueueID, models.GameModeARAM
+	aramGame := aram.Summary()
+	calibrator.Add(&aramGame)
+
+	game := lcutest.NewGame(1, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10).Summary()
+	win, _ := NewDefaultScorer(&cfg).Score("puuid-1", &game)
+	lose, _ := NewDefaultScorer(&cfg).Score("puuid-6", &game)
+	list := calibrator.Result(DefaultCalibratePercentiles, time.Now())
+	// 大乱斗只有1局,没有玩家达到3局
+	if len(list) != 1 || list[0].Mode != "rift" || list[0].Players != 10 || list[0].Games != 3 {
+		t.Fatalf("result = %+v", list)
+	}
+	horse := list[0].Horse
+	if horse[0].Score != math.Floor(win.Value()*10)/10 || horse[2].Score != math.Floor(lose.Value()*10)/10 ||
+		horse[4] != cfg.Horse[4] {
+		t.Errorf("前10%%为胜方得分,前60%%为败方得分, got %+v", horse)
+	}
+
+	ApplyCalibration(&cfg, list)
+	if cfg.Horse != horse || cfg.ModeByName("rift").Horse == nil || cfg.ModeByName("aram").Horse != nil {
+		t.Errorf("apply = %+v", cfg)
+	}
+	if global.DefaultAppConf.CalcScore.Modes[0].Horse != nil {
+		t.Error("不应修改默认配置")
+	}
+}
+
+func TestBacktester(t *testing.T) {
+	cfg := global.DefaultAppConf.CalcScore
+	cfg.ScoreModels = []conf.ScoreModelConf{{Name: "flat", Rules: []string{"kills > 100 => 10"}}}
+	addGames := func(backtester *Backtester) {
+		// 倒序加入,回放时按时间排序
+		for i := 4; i > 0; i-- {
+			g := lcutest.NewGame(int64(i), time.Now().Add(time.Hour*time.Duration(i-5)), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+			// 胜方死亡更少,kda微调得分更高
+			for j := 0; j < 5; j++ {
+				g.Players[j].Deaths = 2
+			}
+			game := g.Summary()
+			backtester.Add(&game)
+		}
+	}
+	backtester := NewBacktester(&cfg, 1, 10)
+	addGames(backtester)
+	results := backtester.Result()
+	if len(results) != 2 {
+		t.Fatalf("results = %+v", results)
+	}
+	// 第一局没有历史得分,之后每局胜方的历史得分都更高
+	def := results[0]
+	if def.Scorer != conf.DefaultScorerName || def.Games != 3 || def.Correct != 3 || def.Accuracy != 1 ||
+		def.Brier >= 0.25 {
+		t.Errorf("default = %+v", def)
+	}
+	binGames := 0
+	for _, bin := range def.Bins {
+		binGames += bin.Games
+		if bin.Games > 0 && (bin.Predicted < bin.Low || bin.Predicted > bin.High || bin.Actual != 1) {
+			t.Errorf("bin = %+v", bin)
+		}
+	}
+	if binGames != 3 {
+		t.Errorf("校准表合计应为3局, got %d", binGames)
+	}
+	// 同时按配置中的 winProbability 模型换算胜率,系数越大预测越极端
+	if winProb := def.WinProbability; winProb.Games != 3 || winProb.Correct != 3 || winProb.Brier >= 0.25 {
+		t.Errorf("default winProbability = %+v", winProb)
+	}
+	cfg.WinProbability.Coefficient *= 10
+	steep := NewBacktester(&cfg, 1, 10)
+	addGames(steep)
+	if res := steep.Result()[0]; res.WinProbability.Brier >= def.WinProbability.Brier ||
+		res.Brier != def.Brier {
+		t.Errorf("winProbability 系数应只影响对应结果: %+v", res)
+	}
+	// 得分都相同时预测胜率为50%,没有预测正确的对局
+	if flat := results[1]; flat.Games != 3 || flat.Correct != 0 || math.Abs(flat.Brier-0.25) > 1e-9 ||
+		math.Abs(flat.WinProbability.Brier-0.25) > 1e-9 {
+		t.Errorf("flat = %+v", flat)
+	}
+}
+
+func TestEstimateWin(t *testing.T) {
+	cfg := conf.WinProbConf{Enabled: true, Coefficient: 0.1, Baseline: 100}
+	if _, ok := EstimateWin(nil, []float64{100}, cfg); ok {
+		t.Fatal("没有我方得分时不应预估")
+	}
+	// 对方未知时按基准分计算
+	estimate, ok := EstimateWin([]float64{100, 100}, nil, cfg)
+	if !ok || estimate.TheirKnown || estimate.Probability != 0.5 {
+		t.Fatalf("estimate = %+v", estimate)
+	}
+	estimate, _ = EstimateWin([]float64{110, 120}, []float64{100, 100}, cfg)
+	if !estimate.TheirKnown || estimate.OurScore != 115 || math.Abs(estimate.Probability-0.8176) > 0.001 {
+		t.Fatalf("estimate = %+v", estimate)
+	}
+	cfg.Enabled = false
+	if _, ok := EstimateWin([]float64{100}, nil, cfg); ok {
+		t.Fatal("未开启时不应预估")
+	}
+}
+
+func TestChampionStats(t *testing.T) {
+	game := func(id int64, championID int, win bool, kills int) lcu.GameInfo {
+		g := lcutest.NewGame(id, time.Now(), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+		g.Players[0].ChampionID, g.Players[0].Win, g.Players[0].Kills = championID, win, kills
+		return g.InfoFor("puuid-1")
+	}
+	remake := game(4, 30, true, 0)
+	remake.GameDuration = 120
+	history := []lcu.GameInfo{game(1, 10, true, 4), game(2, 10, false, 8), game(3, 20, true, 1), remake}
+	// 只有第一局属于当前模式
+	gameScoreList := []lcu.GameScore{{GameID: 1, ChampionID: 10, Score: 120}}
+	stats := ChampionStats(history, gameScoreList)
+	if len(stats) != 2 || stats[0].ChampionID != 10 || stats[1].ChampionID != 20 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	want := lcu.ChampionStat{ChampionID: 10, Games: 2, Wins: 1, KDA: [3]float64{6, stats[0].KDA[1], stats[0].KDA[2]},
+		ScoredGames: 1, Score: 120}
+	if stats[0] != want {
+		t.Errorf("stats[0] = %+v, want %+v", stats[0], want)
+	}
+	user := lcu.UserScore{Champions: stats}
+	if stat := user.ChampionStat(20); stat == nil || stat.Games != 1 || stat.ScoredGames != 0 {
+		t.Errorf("champion 20 = %+v", stat)
+	}
+	if user.ChampionStat(30) != nil {
+		t.Error("重开的对局不应统计")
+	}
+}
@@ -0,0 +1,104 @@
+package scorer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+	"github.com/beastars1/lol-prophet-gui/services/lcu/models"
+)
+
+// accountSignal 一个判断信号,命中时计入权重
+type accountSignal struct {
+	weight float64
+	hit    bool
+	reason string
+}
+
+// CheckAccount 根据召唤师等级和近期战绩判断疑似小号或代练/共享账号的可能性,未开启时返回空
+// 可能性为命中信号的权重之和除以全部信号的权重之和,gameList 为玩家自己的战绩
+// historyDepth 为查询的战绩数量,查到的战绩少于该值时说明账号的全部战绩都已查到
+func CheckAccount(level int, gameList []lcu.GameInfo, historyDepth int, cfg conf.SmurfConf) *lcu.AccountCheck {
+	if !cfg.Enabled {
+		return nil
+	}
+	games := make([]lcu.GameInfo, 0, len(gameList))
+	for _, game := range gameList {
+		if game.GameDuration >= remakeDurationSec && len(game.Participants) > 0 {
+			games = append(games, game)
+		}
+	}
+	wins, kdaList, champions := 0, make([]float64, 0, len(games)), make(map[models.Champion]struct{}, len(games))
+	for _, game := range games {
+		participant := game.Participants[0]
+		if participant.Stats.Win {
+			wins++
+		}
+		deaths := participant.Stats.Deaths
+		if deaths == 0 {
+			deaths = 1
+		}
+		kdaList = append(kdaList, float64(participant.Stats.Kills+participant.Stats.Assists)/float64(deaths))
+		champions[participant.ChampionId] = struct{}{}
+	}
+	// 对局太少时只看等级和对局数
+	enough := len(games) >= cfg.MinGames && len(games) > 0
+	winRate, avgKDA, kdaSpread, championRate := 0.0, 0.0, 0.0, 0.0
+	if enough {
+		winRate = float64(wins) * 100 / float64(len(games))
+		avgKDA, kdaSpread = meanStd(kdaList)
+		championRate = float64(len(champions)) * 100 / float64(len(games))
+	}
+	w, b := cfg.SmurfWeights, cfg.BoostedWeights
+	smurf := []accountSignal{
+		{w.LowLevel, level < cfg.LowLevel, fmt.Sprintf("召唤师等级%d", level)},
+		{w.FewGames, len(gameList) < historyDepth && len(gameList) < cfg.FewGames,
+			fmt.Sprintf("全部只有%d局战绩", len(gameList))},
+		{w.HighWinRate, enough && winRate >= cfg.HighWinRate, fmt.Sprintf("近%d局胜率%.0f%%", len(games), winRate)},
+		{w.HighKDA, enough && avgKDA >= cfg.HighKDA, fmt.Sprintf("平均kda %.1f", avgKDA)},
+		{w.FewChampions, enough && len(champions) <= cfg.FewChampions, fmt.Sprintf("只用了%d个英雄", len(champions))},
+	}
+	boosted := []accountSignal{
+		{b.KDASpread, enough && kdaSpread >= cfg.KDASpread, fmt.Sprintf("kda忽高忽低,标准差%.1f", kdaSpread)},
+		{b.ManyChampions, enough && championRate >= cfg.ManyChampionRate,
+			fmt.Sprintf("%d局用了%d个英雄", len(games), len(champions))},
+		{b.WinWithLowKDA, enough && winRate >= cfg.HighWinRate && avgKDA < cfg.LowKDA,
+			fmt.Sprintf("胜率%.0f%%但平均kda只有%.1f", winRate, avgKDA)},
+	}
+	res := &lcu.AccountCheck{}
+	res.Smurf, res.SmurfReasons = accountLikelihood(smurf)
+	res.Boosted, res.BoostedReasons = accountLikelihood(boosted)
+	return res
+}
+
+func accountLikelihood(signals []accountSignal) (float64, []string) {
+	total, hit := 0.0, 0.0
+	reasons := make([]string, 0, len(signals))
+	for _, signal := range signals {
+		total += signal.weight
+		if signal.hit && signal.weight > 0 {
+			hit += signal.weight
+			reasons = append(reasons, signal.reason)
+		}
+	}
+	if total <= 0 {
+		return 0, nil
+	}
+	return hit / total, reasons
+}
+
+func meanStd(list []float64) (float64, float64) {
+	if len(list) == 0 {
+		return 0, 0
+	}
+	sum, square := 0.0, 0.0
+	for _, v := range list {
+		sum += v
+	}
+	mean := sum / float64(len(list))
+	for _, v := range list {
+		square += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(square / float64(len(list)))
+}
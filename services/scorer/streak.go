@@ -0,0 +1,66 @@
+package scorer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+)
+
+// remakeDurationSec 短于该时长的对局视为重开,不计入胜负
+const remakeDurationSec = 5 * 60
+
+// CalcStreak 根据玩家的战绩列表统计连胜连败、近期胜率、本次游戏局数以及是否上头,未开启时返回空
+// gameList 为玩家自己的战绩,每局只包含玩家自己的数据
+func CalcStreak(gameList []lcu.GameInfo, cfg conf.StreakConf, now time.Time) *lcu.Streak {
+	if !cfg.Enabled {
+		return nil
+	}
+	games := make([]lcu.GameInfo, 0, len(gameList))
+	for _, game := range gameList {
+		if game.GameDuration >= remakeDurationSec && len(game.Participants) > 0 {
+			games = append(games, game)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].GameCreationDate.After(games[j].GameCreationDate)
+	})
+	streak := &lcu.Streak{}
+	sessionGap := time.Duration(cfg.SessionGapHours * float64(time.Hour))
+	tiltSince := now.Add(-time.Duration(cfg.TiltHours * float64(time.Hour)))
+	tiltLosses := 0
+	for i, game := range games {
+		win := game.Participants[0].Stats.Win
+		// 连胜连败
+		if i == streak.Wins+streak.Losses {
+			if win && streak.Losses == 0 {
+				streak.Wins++
+			} else if !win && streak.Wins == 0 {
+				streak.Losses++
+			}
+		}
+		if i < cfg.RecentGames {
+			streak.RecentGames++
+			if win {
+				streak.RecentWins++
+			}
+		}
+		// 本次游戏: 从最近一局往前,每局结束到下一局开始不超过 sessionGap
+		if i == streak.SessionGames {
+			next := now
+			if i > 0 {
+				next = games[i-1].GameCreationDate
+			}
+			end := game.GameCreationDate.Add(time.Duration(game.GameDuration) * time.Second)
+			if next.Sub(end) <= sessionGap {
+				streak.SessionGames++
+			}
+		}
+		if !win && game.GameCreationDate.After(tiltSince) {
+			tiltLosses++
+		}
+	}
+	streak.Tilt = tiltLosses >= cfg.TiltLosses
+	return streak
+}
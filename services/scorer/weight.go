@@ -0,0 +1,87 @@
+package scorer
+
+import (
+	"math"
+	"time"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+	"github.com/beastars1/lol-prophet-gui/services/lcu"
+)
+
+// WeightedScore 加权后的总分
+type WeightedScore struct {
+	Score          float64
+	EffectiveGames float64 // 有效对局数 (Σw)²/Σw²
+	Confidence     float64 // 可信度 0-1
+}
+
+// Weigh 按配置的加权方式计算总分,并把每局归一化后的权重写回 GameScore.Weight
+// gameScoreList 需要按时间倒序,没有对局时为默认分数
+func Weigh(gameScoreList []lcu.GameScore, cfg conf.WeightingConf, now time.Time) WeightedScore {
+	if len(gameScoreList) == 0 {
+		return WeightedScore{Score: DefaultScore}
+	}
+	weights := gameWeights(gameScoreList, cfg, now)
+	totalWeight, totalSquare, total := 0.0, 0.0, 0.0
+	for i, w := range weights {
+		totalWeight += w
+		totalSquare += w * w
+		total += w * gameScoreList[i].Score
+	}
+	if totalWeight <= 0 {
+		return WeightedScore{Score: DefaultScore}
+	}
+	for i, w := range weights {
+		gameScoreList[i].Weight = w / totalWeight
+	}
+	effective := totalWeight * totalWeight / totalSquare
+	return WeightedScore{
+		Score:          roundScore(total / totalWeight),
+		EffectiveGames: effective,
+		Confidence:     effective / (effective + cfg.ConfidenceGames),
+	}
+}
+
+func gameWeights(gameScoreList []lcu.GameScore, cfg conf.WeightingConf, now time.Time) []float64 {
+	weights := make([]float64, len(gameScoreList))
+	switch cfg.Model {
+	case conf.WeightingDecay:
+		for i, game := range gameScoreList {
+			hours := math.Max(now.Sub(game.GameCreation).Hours(), 0)
+			weights[i] = math.Pow(0.5, hours/cfg.HalfLifeHours)
+		}
+	case conf.WeightingRank:
+		for i := range gameScoreList {
+			weights[i] = math.Pow(cfg.RankDecay, float64(i))
+		}
+	default:
+		// 近期对局平分 stepWeight,其余对局平分剩下的权重,只有一类对局时所有对局权重相同
+		recent := 0
+		for _, game := range gameScoreList {
+			if isRecentGame(game, cfg, now) {
+				recent++
+			}
+		}
+		other := len(gameScoreList) - recent
+		for i, game := range gameScoreList {
+			switch {
+			case recent == 0 || other == 0:
+				weights[i] = 1
+			case isRecentGame(game, cfg, now):
+				weights[i] = cfg.StepWeight / float64(recent)
+			default:
+				weights[i] = (1 - cfg.StepWeight) / float64(other)
+			}
+		}
+	}
+	return weights
+}
+
+func isRecentGame(game lcu.GameScore, cfg conf.WeightingConf, now time.Time) bool {
+	return now.Before(game.GameCreation.Add(time.Duration(cfg.StepHours * float64(time.Hour))))
+}
+
+// roundScore 去掉加权平均的浮点误差,避免恰好在分数线上的得分被判为低一档
+func roundScore(score float64) float64 {
+	return math.Round(score*1e6) / 1e6
+}
@@ -0,0 +1,45 @@
+package scorer
+
+import (
+	"math"
+
+	"github.com/beastars1/lol-prophet-gui/conf"
+)
+
+// WinEstimate 队伍胜率预估
+type WinEstimate struct {
+	OurScore    float64 `json:"ourScore"`   // 我方平均得分
+	TheirScore  float64 `json:"theirScore"` // 对方平均得分,不知道对方时为 baseline
+	TheirKnown  bool    `json:"theirKnown"`
+	Probability float64 `json:"probability"` // 我方胜率 0-1
+}
+
+// EstimateWin 根据双方玩家得分预估我方胜率,theirs 为空时对方按 baseline 计算,未开启或我方没有得分时返回false
+func EstimateWin(ours []float64, theirs []float64, cfg conf.WinProbConf) (*WinEstimate, bool) {
+	if !cfg.Enabled || len(ours) == 0 {
+		return nil, false
+	}
+	estimate := &WinEstimate{
+		OurScore:   average(ours),
+		TheirScore: cfg.Baseline,
+		TheirKnown: len(theirs) > 0,
+	}
+	if estimate.TheirKnown {
+		estimate.TheirScore = average(theirs)
+	}
+	estimate.Probability = TeamWinProbability(estimate.OurScore, estimate.TheirScore, cfg)
+	return estimate, true
+}
+
+// TeamWinProbability 逻辑回归模型,根据双方平均得分差计算我方胜率
+func TeamWinProbability(ours float64, theirs float64, cfg conf.WinProbConf) float64 {
+	return 1 / (1 + math.Exp(-(cfg.Intercept + cfg.Coefficient*(ours-theirs))))
+}
+
+func average(list []float64) float64 {
+	total := 0.0
+	for _, v := range list {
+		total += v
+	}
+	return total / float64(len(list))
+}
@@ -16,6 +16,13 @@ type (
 	}
 )
 
+const (
+	MsgTypeWinProbability MsgType = "winProbability" // 我方胜率预估
+)
+
+// broadcastBufSize 广播队列长度,队列满时丢弃消息
+const broadcastBufSize = 16
+
 var ServerHub = NewHub()
 
 func Init() {
@@ -23,7 +30,7 @@ func Init() {
 }
 func NewHub() *Hub {
 	return &Hub{
-		broadcast:  make(chan []byte),
+		broadcast:  make(chan []byte, broadcastBufSize),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
@@ -53,7 +60,11 @@ func (h *Hub) Run() {
 	}
 }
 
+// BroadcastMsg 广播消息给所有客户端,不阻塞调用方
 func BroadcastMsg(msg Msg) {
 	bts, _ := json.Marshal(msg)
-	ServerHub.broadcast <- bts
+	select {
+	case ServerHub.broadcast <- bts:
+	default:
+	}
 }
@@ -0,0 +1,106 @@
+package lol_prophet_gui
+
+import (
+	"time"
+)
+
+type (
+	ConnState      string // lcu连接状态
+	ConnStateEvent struct {
+		From ConnState
+		To   ConnState
+		Port int
+		Err  error
+		Time time.Time
+	}
+	ConnStateListener func(evt ConnStateEvent)
+	backoff           struct {
+		min  time.Duration
+		max  time.Duration
+		curr time.Duration
+	}
+)
+
+// connState
+const (
+	ConnStateSearching     ConnState = "searching"     // 查找客户端中
+	ConnStateConnecting    ConnState = "connecting"    // 连接websocket中
+	ConnStateAuthenticated ConnState = "authenticated" // 已获取当前召唤师
+	ConnStateSubscribed    ConnState = "subscribed"    // 已订阅客户端事件
+	ConnStateLost          ConnState = "lost"          // 连接断开
+)
+
+const (
+	minReconnectDelay   = time.Second
+	maxReconnectDelay   = time.Second * 30
+	rediscoveryInterval = time.Second * 5
+)
+
+func newBackoff(min, max time.Duration) *backoff {
+	return &backoff{min: min, max: max}
+}
+
+// Next 返回下一次等待时长,每次翻倍直到上限
+func (b *backoff) Next() time.Duration {
+	if b.curr == 0 {
+		b.curr = b.min
+	} else {
+		b.curr *= 2
+	}
+	if b.curr > b.max {
+		b.curr = b.max
+	}
+	return b.curr
+}
+
+func (b *backoff) Reset() {
+	b.curr = 0
+}
+
+// OnConnStateChange 注册连接状态变化监听,用于gui展示和日志
+func (p *Prophet) OnConnStateChange(fn ConnStateListener) {
+	p.mu.Lock()
+	p.connListeners = append(p.connListeners, fn)
+	p.mu.Unlock()
+}
+
+func (p *Prophet) ConnState() ConnState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.connState
+}
+
+func (p *Prophet) setConnState(state ConnState, port int, err error) {
+	p.mu.Lock()
+	if p.connState == state {
+		p.mu.Unlock()
+		return
+	}
+	evt := ConnStateEvent{
+		From: p.connState,
+		To:   state,
+		Port: port,
+		Err:  err,
+		Time: time.Now(),
+	}
+	p.connState = state
+	p.lcuActive = state == ConnStateAuthenticated || state == ConnStateSubscribed
+	listeners := make([]ConnStateListener, len(p.connListeners))
+	copy(listeners, p.connListeners)
+	p.mu.Unlock()
+	for _, fn := range listeners {
+		fn(evt)
+	}
+}
+
+// sleep 等待d,若 Prophet 已停止返回false
+func (p *Prophet) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-p.ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}